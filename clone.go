@@ -0,0 +1,107 @@
+package flatten
+
+import (
+	"reflect"
+
+	"github.com/MarkRosemaker/openapi"
+)
+
+// Clone returns a deep copy of the document.
+//
+// Objects that are shared in the document, e.g. a component and the value of a resolved reference to it,
+// are shared in the copy as well, so references stay resolved, including those the loader resolved
+// to other documents. Regular expressions are immutable and not copied.
+func Clone(d *openapi.Document) *openapi.Document {
+	c := &copier{seen: map[copied]reflect.Value{}}
+	return c.pointer(reflect.ValueOf(d)).Interface().(*openapi.Document)
+}
+
+// copier makes deep copies of values, copying every pointer only once.
+type copier struct {
+	seen map[copied]reflect.Value
+}
+
+// copied identifies a pointer that was copied; the type tells apart a struct and its first field.
+type copied struct {
+	ptr uintptr
+	tp  reflect.Type
+}
+
+// pointer returns a copy of what the pointer points to, or the copy made before.
+func (c *copier) pointer(src reflect.Value) reflect.Value {
+	if src.IsNil() || src.Type() == typeRegexp {
+		return src
+	}
+
+	key := copied{src.Pointer(), src.Type()}
+	if dst, ok := c.seen[key]; ok {
+		return dst
+	}
+
+	dst := reflect.New(src.Type().Elem())
+	c.seen[key] = dst // before copying, so cycles end here
+	c.copy(dst.Elem(), src.Elem())
+
+	return dst
+}
+
+// copy sets dst to a deep copy of src.
+func (c *copier) copy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		dst.Set(c.pointer(src))
+	case reflect.Struct:
+		dst.Set(src) // including unexported fields, e.g. the index for the ordering
+
+		for i := range src.NumField() {
+			if src.Type().Field(i).IsExported() {
+				c.copy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		for iter := src.MapRange(); iter.Next(); {
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copy(v, iter.Value())
+			m.SetMapIndex(iter.Key(), v)
+		}
+
+		dst.Set(m)
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		if src.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(s, src) // raw JSON, e.g. examples and extensions
+		} else {
+			for i := range src.Len() {
+				c.copy(s.Index(i), src.Index(i))
+			}
+		}
+
+		dst.Set(s)
+	case reflect.Array:
+		for i := range src.Len() {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+
+		v := reflect.New(src.Elem().Type()).Elem()
+		c.copy(v, src.Elem())
+		dst.Set(v)
+	default:
+		dst.Set(src)
+	}
+}
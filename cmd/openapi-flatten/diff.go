@@ -0,0 +1,238 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+//
+// This file is adapted from internal/diff of the Go standard library,
+// https://go.googlesource.com/go/+/refs/heads/master/src/internal/diff/diff.go,
+// which is distributed under the following license:
+//
+//	Copyright 2009 The Go Authors.
+//
+//	Redistribution and use in source and binary forms, with or without
+//	modification, are permitted provided that the following conditions are
+//	met:
+//
+//	   * Redistributions of source code must retain the above copyright
+//	notice, this list of conditions and the following disclaimer.
+//	   * Redistributions in binary form must reproduce the above
+//	copyright notice, this list of conditions and the following disclaimer
+//	in the documentation and/or other materials provided with the
+//	distribution.
+//	   * Neither the name of Google LLC nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+//	THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+//	"AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+//	LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+//	A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+//	OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+//	SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+//	LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+//	DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+//	THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//	(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+//	OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pair is a pair of line indexes in the old and the new text.
+type pair struct{ x, y int }
+
+// unifiedDiff returns a unified diff of the old and the new text with three lines of context,
+// or nil if they are equal.
+//
+// Like Go's internal/diff, which it is adapted from, it anchors the diff on the lines that are unique in both texts,
+// so it runs in O(n log n) time even for large specs, at the cost of not always finding the minimal diff.
+func unifiedDiff(oldName string, old []byte, newName string, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	x, y := lines(old), lines(new)
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", oldName, newName)
+
+	const context = 3
+
+	var (
+		done  pair     // the lines x[:done.x] and y[:done.y] were handled
+		chunk pair     // the first lines of the current chunk
+		count pair     // the number of lines of each text in the current chunk
+		ctext []string // the lines of the current chunk
+	)
+
+	for _, m := range anchors(x, y) {
+		if m.x < done.x {
+			continue // already handled when expanding an earlier match
+		}
+
+		// expand the match as far as possible, so that x[start.x:end.x] == y[start.y:end.y]
+		start := m
+		for start.x > done.x && start.y > done.y && x[start.x-1] == y[start.y-1] {
+			start.x--
+			start.y--
+		}
+
+		end := m
+		for end.x < len(x) && end.y < len(y) && x[end.x] == y[end.y] {
+			end.x++
+			end.y++
+		}
+
+		// add the differing lines before the match to the chunk
+		for _, s := range x[done.x:start.x] {
+			ctext = append(ctext, "-"+s)
+			count.x++
+		}
+
+		for _, s := range y[done.y:start.y] {
+			ctext = append(ctext, "+"+s)
+			count.y++
+		}
+
+		// continue the chunk if there are too few common lines before the next difference
+		atEOF := end.x >= len(x) && end.y >= len(y)
+		if !atEOF && (end.x-start.x < context || (len(ctext) > 0 && end.x-start.x < 2*context)) {
+			for _, s := range x[start.x:end.x] {
+				ctext = append(ctext, " "+s)
+				count.x++
+				count.y++
+			}
+
+			done = end
+			continue
+		}
+
+		// end the chunk with common lines as context
+		if len(ctext) > 0 {
+			n := min(end.x-start.x, context)
+			for _, s := range x[start.x : start.x+n] {
+				ctext = append(ctext, " "+s)
+				count.x++
+				count.y++
+			}
+
+			done = pair{start.x + n, start.y + n}
+
+			// line numbers start at 1, unless the chunk is empty on that side
+			if count.x > 0 {
+				chunk.x++
+			}
+
+			if count.y > 0 {
+				chunk.y++
+			}
+
+			fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", chunk.x, count.x, chunk.y, count.y)
+			for _, s := range ctext {
+				out.WriteString(s)
+			}
+
+			count = pair{}
+			ctext = ctext[:0]
+		}
+
+		if atEOF {
+			break
+		}
+
+		// start a new chunk with common lines as context
+		chunk = pair{end.x - context, end.y - context}
+		for _, s := range x[chunk.x:end.x] {
+			ctext = append(ctext, " "+s)
+			count.x++
+			count.y++
+		}
+
+		done = end
+	}
+
+	return out.Bytes()
+}
+
+// lines splits the text into lines, each ending with a newline.
+func lines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+
+	l := strings.SplitAfter(string(b), "\n")
+	if l[len(l)-1] == "" {
+		return l[:len(l)-1]
+	}
+
+	l[len(l)-1] += "\n\\ No newline at end of file\n"
+	return l
+}
+
+// anchors returns the pairs of lines that are unique in both texts and appear in the same order,
+// using the longest increasing subsequence, between the sentinels {0, 0} and {len(x), len(y)}.
+func anchors(x, y []string) []pair {
+	// count the lines of x as 0, -1 or -2 (many) and those of y as 0, -4 or -8 (many)
+	m := map[string]int{}
+	for _, s := range x {
+		if c := m[s]; c > -2 {
+			m[s] = c - 1
+		}
+	}
+
+	for _, s := range y {
+		if c := m[s]; c > -8 {
+			m[s] = c - 4
+		}
+	}
+
+	// xi and yi are the indexes of the lines that are unique in both texts,
+	// and inv[i] is the j for which x[xi[i]] == y[yi[j]]
+	var xi, yi, inv []int
+	for i, s := range y {
+		if m[s] == -1+-4 {
+			m[s] = len(yi)
+			yi = append(yi, i)
+		}
+	}
+
+	for i, s := range x {
+		if j, ok := m[s]; ok && j >= 0 {
+			xi = append(xi, i)
+			inv = append(inv, j)
+		}
+	}
+
+	// find the longest increasing subsequence of inv
+	n := len(inv)
+	tails := make([]int, n)
+	length := make([]int, n)
+	for i := range tails {
+		tails[i] = n + 1
+	}
+
+	k := 0
+	for i, j := range inv {
+		l := sort.SearchInts(tails, j)
+		tails[l] = j
+		length[i] = l + 1
+		k = max(k, length[i])
+	}
+
+	seq := make([]pair, k+2)
+	seq[k+1] = pair{len(x), len(y)}
+
+	last := n
+	for i := n - 1; i >= 0 && k > 0; i-- {
+		if length[i] == k && inv[i] < last {
+			seq[k] = pair{xi[i], yi[inv[i]]}
+			last = inv[i]
+			k--
+		}
+	}
+
+	return seq
+}
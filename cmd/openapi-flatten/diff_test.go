@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name, old, new, want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"change", "a\nb\nc\nd\ne\nf\ng\nh\n", "a\nb\nc\nd\nE\nf\ng\nh\n",
+			"--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		{
+			"no newline at end", "a\nb", "a\nc",
+			"--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			"two chunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"--- a/f\n+++ b/f\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(unifiedDiff("a/f", []byte(tc.old), "b/f", []byte(tc.new))); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
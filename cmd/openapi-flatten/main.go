@@ -1,56 +1,511 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 
+	"github.com/MarkRosemaker/json2yaml"
 	"github.com/MarkRosemaker/openapi"
 	flatten "github.com/MarkRosemaker/openapi-flatten"
+	"github.com/MarkRosemaker/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 func main() {
 	if err := run(context.Background()); err != nil {
 		fmt.Fprintf(os.Stderr, "openapi-flatten: %v\n", err)
-		flag.Usage()
+		if errors.As(err, new(usageError)) {
+			flag.Usage()
+		}
+
 		os.Exit(1)
 	}
 }
 
+// errNotFlat is returned by -check if flattening would change the spec.
+var errNotFlat = errors.New("spec is not flat")
+
+// errDiagnostics is returned if flattening found problems of severity error, after printing them.
+var errDiagnostics = errors.New("flattening found errors")
+
+// usageError is an error in the use of the flags or arguments.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// config holds the flags that apply to each spec.
+type config struct {
+	outPath, format, reportFormat string
+	inPlace, check, diff, dryRun  bool
+	names                         map[string]string
+	skipUnsupported               bool
+}
+
+// result is the outcome of processing a spec.
+type result struct {
+	// stdout and stderr hold the output for the spec, so that the output of specs
+	// processed concurrently is not interleaved
+	stdout, stderr bytes.Buffer
+	report         *flatten.Report
+	err            error
+}
+
 func run(ctx context.Context) error {
-	var specPath string
-	flag.StringVar(&specPath, "spec", "api/openapi.json", "path to OpenAPI spec file")
+	var specPath, namesPath string
+	var jobs int
+	c := &config{}
+	flag.StringVar(&specPath, "spec", "api/openapi.json", "path to OpenAPI spec file, or \"-\" for standard input, if no arguments are given")
+	flag.StringVar(&c.outPath, "o", "", "path to write the flattened spec to, or \"-\" for standard output (default \"-\")")
+	flag.StringVar(&c.outPath, "out", "", "alias for -o")
+	flag.BoolVar(&c.inPlace, "w", false, "write the flattened spec to the input file")
+	flag.BoolVar(&c.check, "check", false, "do not write the spec, but fail and list the changes if it is not flat")
+	flag.BoolVar(&c.diff, "diff", false, "do not write the spec, but print a unified diff of the changes")
+	flag.BoolVar(&c.dryRun, "dry-run", false, "do not write the spec, but print the report of the changes")
+	flag.StringVar(&c.format, "format", "auto", "write the flattened spec as \"json\", \"yaml\" or, with \"auto\", in the format of the input")
+	flag.StringVar(&namesPath, "names", "", "path to a JSON or YAML file mapping locations of inline objects to component names")
+	flag.StringVar(&c.reportFormat, "report", "", "print a report of the changes as \"text\" or \"json\"")
+	flag.BoolVar(&c.skipUnsupported, "skip-unsupported", false, "leave schemas that can not be flattened inline and print them as diagnostics")
+	flag.IntVar(&jobs, "j", runtime.GOMAXPROCS(0), "number of specs to process concurrently")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [spec, glob or directory ...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	doc, err := openapi.LoadFromFile(specPath)
+	switch c.format {
+	case "auto", "json", "yaml":
+	default:
+		return usageError(fmt.Sprintf("unknown format %q", c.format))
+	}
+
+	switch c.reportFormat {
+	case "", "text", "json":
+	default:
+		return usageError(fmt.Sprintf("unknown report format %q", c.reportFormat))
+	}
+
+	if jobs < 1 {
+		return usageError("-j must be at least 1")
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{specPath}
+	} else if flagSet("spec") {
+		return usageError("give the specs either with -spec or as arguments")
+	}
+
+	specs, err := expandSpecs(args)
+	if err != nil {
+		return err
+	}
+
+	if len(specs) > 1 {
+		switch {
+		case slices.Contains(specs, "-"):
+			return usageError("standard input can only be read for a single spec")
+		case c.outPath != "":
+			return usageError("-o needs a single spec, use -w to write several specs in place")
+		case !c.inPlace && !c.check && !c.diff && !c.dryRun:
+			return usageError("several specs can only be written in place with -w, or checked with -check, -diff or -dry-run")
+		}
+	}
+
+	if err := c.checkOutput(specs[0]); err != nil {
+		return err
+	}
+
+	if namesPath != "" {
+		if c.names, err = readNames(namesPath); err != nil {
+			return err
+		}
+	}
+
+	// process the specs with a bounded number of workers
+	results := make([]*result, len(specs))
+	next := make(chan int)
+	wg := &sync.WaitGroup{}
+	for range min(jobs, len(specs)) {
+		wg.Go(func() {
+			for i := range next {
+				results[i] = c.process(specs[i])
+			}
+		})
+	}
+
+	for i := range specs {
+		next <- i
+	}
+
+	close(next)
+	wg.Wait()
+
+	// print the output in the order of the specs
+	var errs []error
+	for i, r := range results {
+		// the diff names the spec itself
+		if len(specs) > 1 && r.stdout.Len() > 0 && !c.diff {
+			fmt.Printf("==> %s <==\n", specs[i])
+		}
+
+		if _, err := io.Copy(os.Stdout, &r.stdout); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(os.Stderr, &r.stderr); err != nil {
+			return err
+		}
+
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", specs[i], r.err))
+		}
+	}
+
+	if len(specs) > 1 {
+		if err := printSummary(os.Stderr, specs, results); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkOutput validates where the spec is written to and defaults the output to standard output.
+func (c *config) checkOutput(spec string) error {
+	switch {
+	case c.check && (c.inPlace || c.outPath != ""):
+		return usageError("-check does not write the spec, so it can not be combined with -w or -o")
+	case (c.diff || c.dryRun) && (c.inPlace || c.outPath != ""):
+		return usageError("-diff and -dry-run do not write the spec, so they can not be combined with -w or -o")
+	case c.inPlace && spec == "-":
+		return usageError("-w needs an input file")
+	case c.inPlace && c.outPath != "":
+		return usageError("-w and -o are mutually exclusive")
+	case c.inPlace, c.diff || c.dryRun: // written to the input file or not at all
+	case c.outPath == "":
+		c.outPath = "-"
+	case spec != "-" && sameFile(spec, c.outPath):
+		return usageError(fmt.Sprintf("refusing to overwrite %s, use -w to write in place", spec))
+	}
+
+	return nil
+}
+
+// process flattens the spec and writes, checks or compares it, depending on the flags.
+func (c *config) process(specPath string) *result {
+	r := &result{report: &flatten.Report{}}
+	r.err = c.flattenSpec(specPath, r)
+	if r.err == nil && slices.ContainsFunc(r.report.Diagnostics, isError) {
+		r.err = errDiagnostics
+	}
+
+	return r
+}
+
+func (c *config) flattenSpec(specPath string, r *result) error {
+	opts := flatten.Options{
+		Names:           c.names,
+		SkipUnsupported: c.skipUnsupported,
+		Report:          r.report,
+	}
+
+	doc, format, err := load(specPath)
 	if err != nil {
 		return err
 	}
 
+	if c.format != "auto" {
+		format = c.format
+	}
+
+	outPath := c.outPath
+	if c.inPlace {
+		outPath = specPath
+	}
+
 	wasValid := doc.Validate() == nil
 
-	if err := flatten.Document(doc); err != nil {
+	if c.check {
+		err := checkFlat(&r.stdout, doc, opts, c.reportFormat)
+		if err == nil { // a flat spec has no report to list the diagnostics
+			printDiagnostics(&r.stderr, opts.Report)
+		}
+
+		return err
+	}
+
+	var original []byte
+	if c.diff {
+		if original, err = marshal(doc, format); err != nil {
+			return err
+		}
+	}
+
+	if err := flatten.DocumentWithOptions(doc, opts); err != nil {
 		return err
 	}
 
-	// Sort responses and components (but not paths to keep the order)
+	normalize(doc)
+
+	if wasValid {
+		if err := doc.Validate(); err != nil {
+			return fmt.Errorf("produced invalid doc: %w", err)
+		}
+	}
+
+	// keep standard output for the spec or the diff
+	reportOut := &r.stdout
+	if outPath == "-" || c.diff {
+		reportOut = &r.stderr
+	}
+
+	switch {
+	case c.diff:
+		flat, err := marshal(doc, format)
+		if err != nil {
+			return err
+		}
+
+		// name the files like git does, e.g. a/api/openapi.json
+		name := strings.TrimPrefix(filepath.ToSlash(specPath), "/")
+		if specPath == "-" {
+			name = "stdin"
+		}
+
+		r.stdout.Write(unifiedDiff("a/"+name, original, "b/"+name, flat))
+	case c.dryRun: // print the report below
+	default:
+		if err := write(&r.stdout, doc, outPath, format); err != nil {
+			return err
+		}
+	}
+
+	reportFormat := c.reportFormat
+	if c.dryRun {
+		reportFormat = cmp.Or(reportFormat, "text")
+	}
+
+	if reportFormat != "" {
+		return printReport(reportOut, opts.Report, reportFormat)
+	}
+
+	printDiagnostics(&r.stderr, opts.Report)
+
+	return nil
+}
+
+// printDiagnostics writes the diagnostics of the report, one per line.
+func printDiagnostics(w io.Writer, r *flatten.Report) {
+	for _, d := range r.Diagnostics {
+		fmt.Fprintln(w, d)
+	}
+}
+
+// isError tells whether the diagnostic has severity error.
+func isError(d flatten.Diagnostic) bool { return d.Severity == flatten.SeverityError }
+
+// flagSet tells whether the flag with the given name was set on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// checkFlat flattens the spec and returns errNotFlat if that changes it,
+// after printing the report of the changes, by default as text.
+func checkFlat(w io.Writer, doc *openapi.Document, opts flatten.Options, reportFormat string) error {
+	before, err := doc.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	// the loaded document is never written, so it can be flattened as the copy
+	if err := flatten.DocumentWithOptions(doc, opts); err != nil {
+		return err
+	}
+
+	// compare with what would be written
+	normalize(doc)
+
+	after, err := doc.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(before, after) {
+		return nil
+	}
+
+	if err := printReport(w, opts.Report, cmp.Or(reportFormat, "text")); err != nil {
+		return err
+	}
+
+	return errNotFlat
+}
+
+// normalize sorts the responses and components of the flattened document, but not the paths to keep their order.
+func normalize(doc *openapi.Document) {
 	for _, path := range doc.Paths {
 		for _, op := range path.Operations {
 			op.Responses.Sort()
 		}
 	}
+
 	doc.Components.SortMaps()
+}
 
-	if wasValid {
-		if err := doc.Validate(); err != nil {
-			return fmt.Errorf("produced invalid doc: %w", err)
+// load reads the spec from the file, or from standard input if the path is "-".
+// It returns the format of the spec, "json" or "yaml".
+func load(path string) (*openapi.Document, string, error) {
+	if path == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, "", err
 		}
+
+		doc, err := openapi.LoadFromData(b)
+		if !json.Valid(b) {
+			return doc, "yaml", err
+		}
+
+		return doc, "json", err
 	}
 
-	if err := doc.WriteToFile(specPath); err != nil {
+	doc, err := openapi.LoadFromFile(path)
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return doc, "yaml", err
+	default:
+		return doc, "json", err
+	}
+}
+
+// write writes the spec as "json" or "yaml" to the file, or to stdout if the path is "-".
+func write(stdout io.Writer, doc *openapi.Document, path, format string) error {
+	b, err := marshal(doc, format)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if path == "-" {
+		_, err := stdout.Write(b)
+		return err
+	}
+
+	// create the underlying directories if they don't exist
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o644)
+}
+
+// marshal serializes the spec as "json" or "yaml", keeping the order of its keys.
+func marshal(doc *openapi.Document, format string) ([]byte, error) {
+	b, err := doc.ToJSON()
+	if err != nil || format == "json" {
+		return b, err
+	}
+
+	n, err := json2yaml.Convert(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	tagStrings(n, v)
+
+	buf := &bytes.Buffer{}
+	enc := yamlv3.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// tagStrings marks the scalars of the YAML node that are strings in the JSON value,
+// so that e.g. the status code "200" or the enum value "true" stay strings.
+func tagStrings(n *yamlv3.Node, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			n.Content[i].Tag = "!!str"
+			tagStrings(n.Content[i+1], v[n.Content[i].Value])
+		}
+	case []any:
+		for i, el := range n.Content {
+			tagStrings(el, v[i])
+		}
+	case string:
+		n.Tag = "!!str"
+	}
+}
+
+// sameFile tells whether both paths refer to the same existing file.
+func sameFile(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(fa, fb)
+}
+
+// readNames reads the names file, which maps locations of inline objects to component names.
+func readNames(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	if err := yaml.Unmarshal(b, &names); err != nil {
+		return nil, fmt.Errorf("reading names: %w", err)
+	}
+
+	return names, nil
+}
+
+// printReport writes the report in the given format, "text" or "json".
+func printReport(w io.Writer, r *flatten.Report, format string) error {
+	if format == "text" {
+		_, err := io.WriteString(w, r.String())
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
 }
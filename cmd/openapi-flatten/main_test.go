@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcess_ErrorDiagnostics(t *testing.T) {
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {},
+  "components": {
+    "schemas": {
+      "Upload": {"type": "object", "properties": {"file": {"type": "file"}}}
+    }
+  }
+}`
+
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*config{
+		{dryRun: true, format: "auto", skipUnsupported: true},
+		{check: true, format: "auto", skipUnsupported: true},
+	} {
+		r := c.process(path)
+		if !errors.Is(r.err, errDiagnostics) {
+			t.Errorf("check=%t: expected errDiagnostics, got %v", c.check, r.err)
+		}
+
+		if out := r.stdout.String() + r.stderr.String(); !strings.Contains(out, `unimplemented schema ref type "file"`) {
+			t.Errorf("check=%t: expected the diagnostic in the output, got %q", c.check, out)
+		}
+	}
+}
+
+func TestProcess_CheckSorted(t *testing.T) {
+	dir := t.TempDir()
+	for name, schemas := range map[string]string{
+		"sorted.json":   `"A": {"type": "string"}, "B": {"type": "string"}`,
+		"unsorted.json": `"B": {"type": "string"}, "A": {"type": "string"}`,
+	} {
+		spec := `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {},
+  "components": {"schemas": {` + schemas + `}}
+}`
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(spec), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &config{check: true, format: "auto"}
+	if r := c.process(filepath.Join(dir, "sorted.json")); r.err != nil {
+		t.Errorf("sorted: expected no error, got %v", r.err)
+	}
+
+	// writing the spec would sort the components
+	if r := c.process(filepath.Join(dir, "unsorted.json")); !errors.Is(r.err, errNotFlat) {
+		t.Errorf("unsorted: expected errNotFlat, got %v", r.err)
+	}
+}
+
+func TestConfig_CheckOutput(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "openapi.json")
+	if err := os.WriteFile(spec, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		c       *config
+		spec    string
+		err     string
+		outPath string
+	}{
+		{"stdout by default", &config{}, spec, "", "-"},
+		{"other file", &config{outPath: filepath.Join(dir, "out.json")}, spec, "", filepath.Join(dir, "out.json")},
+		{"stdin to stdout", &config{}, "-", "", "-"},
+		{"in place", &config{inPlace: true}, spec, "", ""},
+		{"in place from stdin", &config{inPlace: true}, "-", "-w needs an input file", ""},
+		{"output onto input", &config{outPath: spec}, spec, "refusing to overwrite", ""},
+		{"output onto input by another path", &config{outPath: filepath.Join(dir, ".", "..", filepath.Base(dir), "openapi.json")}, spec, "refusing to overwrite", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.c.checkOutput(tc.spec)
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("expected no error, got %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			case tc.err == "" && tc.c.outPath != tc.outPath:
+				t.Errorf("got output %q, want %q", tc.c.outPath, tc.outPath)
+			}
+		})
+	}
+}
+
+func TestLoad_Format(t *testing.T) {
+	const (
+		jsonSpec = `{"openapi": "3.1.0", "info": {"title": "API", "version": "0.0.1"}, "paths": {}}`
+		yamlSpec = "openapi: 3.1.0\ninfo:\n  title: API\n  version: 0.0.1\npaths: {}\n"
+	)
+
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name, content, want string
+	}{
+		{"openapi.json", jsonSpec, "json"},
+		{"openapi.yaml", yamlSpec, "yaml"},
+		{"openapi.yml", yamlSpec, "yaml"},
+		{"-", jsonSpec, "json"},
+		{"-", yamlSpec, "yaml"},
+	} {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if tc.name == "-" {
+			// read the spec from standard input
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdin := os.Stdin
+			os.Stdin = f
+			t.Cleanup(func() { os.Stdin = stdin; f.Close() })
+			path = "-"
+		}
+
+		doc, format, err := load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if format != tc.want {
+			t.Errorf("%s: got format %q, want %q", tc.name, format, tc.want)
+		}
+
+		if doc.Info.Title != "API" {
+			t.Errorf("%s: got title %q, want %q", tc.name, doc.Info.Title, "API")
+		}
+	}
+}
+
+func TestMarshal_YAMLStrings(t *testing.T) {
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/a": {
+      "get": {
+        "operationId": "GetA",
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Flag": {"type": "string", "enum": ["true", "null"]},
+      "Count": {"type": "integer", "maximum": 200}
+    }
+  }
+}`
+
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, _, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := marshal(doc, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(b)
+	for _, want := range []string{`"200":`, `- "true"`, `- "null"`, "maximum: 200\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the YAML output:\n%s", want, out)
+		}
+	}
+}
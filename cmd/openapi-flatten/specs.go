@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expandSpecs returns the specs given by the arguments: files, "-" for standard input,
+// glob patterns, or directories, which are searched recursively for JSON and YAML files.
+func expandSpecs(args []string) ([]string, error) {
+	var specs []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			specs = append(specs, path)
+		}
+	}
+
+	for _, arg := range args {
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, usageError(fmt.Sprintf("invalid pattern %q", arg))
+			}
+
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no specs match %q", arg)
+			}
+
+			paths = matches
+		}
+
+		for _, path := range paths {
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				add(path) // a missing file is reported when it is loaded
+				continue
+			}
+
+			n := len(specs)
+			if err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				switch {
+				case err != nil:
+					return err
+				case d.IsDir() && p != path && strings.HasPrefix(d.Name(), "."):
+					return filepath.SkipDir // e.g. .git
+				case !d.IsDir() && isSpecFile(p):
+					add(p)
+				}
+
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			if len(specs) == n {
+				return nil, fmt.Errorf("no specs in %s", path)
+			}
+		}
+	}
+
+	return specs, nil
+}
+
+// isSpecFile tells whether the file has the extension of a spec that can be loaded.
+func isSpecFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".json", ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
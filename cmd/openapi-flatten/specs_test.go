@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExpandSpecs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.yaml", "notes.txt", "sub/c.yml", ".git/d.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	specs, err := expandSpecs([]string{dir, filepath.Join(dir, "*.json"), "missing.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "b.yaml"),
+		filepath.Join(dir, "sub", "c.yml"),
+		"missing.json",
+	}
+	if !slices.Equal(specs, want) {
+		t.Errorf("got %v, want %v", specs, want)
+	}
+
+	if _, err := expandSpecs([]string{filepath.Join(dir, "*.xml")}); err == nil {
+		t.Error("expected an error for a pattern without matches")
+	}
+}
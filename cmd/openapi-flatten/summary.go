@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// printSummary writes a table with the result of each spec.
+func printSummary(w io.Writer, specs []string, results []*result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SPEC\tRESULT\tPROMOTIONS\tDIAGNOSTICS")
+
+	failed := 0
+	for i, r := range results {
+		status := "ok"
+		switch {
+		case errors.Is(r.err, errNotFlat):
+			status = "not flat"
+		case r.err != nil:
+			status = "error"
+		}
+
+		if r.err != nil {
+			failed++
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", specs[i], status, len(r.report.Promotions), len(r.report.Diagnostics))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d specs, %d failed\n", len(specs), failed)
+	return err
+}
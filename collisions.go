@@ -0,0 +1,66 @@
+package flatten
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/MarkRosemaker/openapi"
+)
+
+// maxCollisionRuns limits how often nameCollisions flattens a copy of the document.
+const maxCollisionRuns = 4
+
+// nameCollisions returns the names that more than one object asks for, with the locations of those objects,
+// and the locations that objects sharing a component are named by, see flattener.canonical.
+//
+// It flattens copies of the document until neither changes anymore,
+// since nested objects are named after their parents, whose names change when they collide.
+func nameCollisions(d *openapi.Document, opts Options) (map[string][]string, map[string]string, error) {
+	opts.Report = nil
+	opts.PruneComponents = false
+
+	var (
+		collisions map[string][]string
+		canonical  map[string]string
+	)
+
+	for range maxCollisionRuns {
+		f := newFlattener(Clone(d), opts)
+		f.collisions, f.canonical = collisions, canonical
+		f.requested, f.uses = map[string][]string{}, map[string][]string{}
+
+		if err := f.flatten(); err != nil {
+			return nil, nil, err
+		}
+
+		nextCanonical := map[string]string{}
+		for _, locs := range f.uses {
+			first := slices.Min(locs)
+			for _, loc := range locs {
+				nextCanonical[loc] = first
+			}
+		}
+
+		nextCollisions := map[string][]string{}
+		for key, locs := range f.requested {
+			for i, loc := range locs {
+				if c, ok := nextCanonical[loc]; ok {
+					locs[i] = c
+				}
+			}
+
+			slices.Sort(locs)
+			if locs = slices.Compact(locs); len(locs) > 1 {
+				nextCollisions[key] = locs
+			}
+		}
+
+		if maps.EqualFunc(collisions, nextCollisions, slices.Equal) && maps.Equal(canonical, nextCanonical) {
+			break
+		}
+
+		collisions, canonical = nextCollisions, nextCanonical
+	}
+
+	return collisions, canonical, nil
+}
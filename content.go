@@ -5,12 +5,12 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func content(d *openapi.Document, c openapi.Content,
+func (f *flattener) content(c openapi.Content,
 	rspOrReqBodyName, tp string, modeSchema mode,
 ) error {
 	for mr, mt := range c.ByIndex() {
-		if err := mediaType(d, mt,
-			nameMediaType(rspOrReqBodyName, nameMediaRange(mr), tp),
+		if err := f.mediaType(mt,
+			f.namer.MediaType(rspOrReqBodyName, mr, tp),
 			modeSchema); err != nil {
 			return &errpath.ErrKey{Key: string(mr), Err: err}
 		}
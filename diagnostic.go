@@ -0,0 +1,99 @@
+package flatten
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Severity tells how serious a diagnostic is.
+type Severity string
+
+const (
+	// SeverityError means that an object could not be flattened as asked,
+	// e.g. it was left as is or its pinned name was taken.
+	SeverityError Severity = "error"
+	// SeverityWarning means that the document was flattened, but maybe not as intended.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found while flattening a document.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	// Location is the location of the problem, e.g. `paths["/pets"].GET.responses["200"].content["application/json"].schema`.
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// String formats the diagnostic like "error: <location>: <message>".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Location, d.Message)
+}
+
+// DiagnosticsError is returned if Options.Report is nil and diagnostics of severity error were found,
+// so that they are not lost.
+type DiagnosticsError struct {
+	Diagnostics []Diagnostic
+}
+
+// Error lists the diagnostics, one per line.
+func (e *DiagnosticsError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = d.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// errorDiagnostics returns a DiagnosticsError with the diagnostics of severity error, or nil if there are none.
+func (f *flattener) errorDiagnostics() error {
+	var errs []Diagnostic
+	for _, d := range f.diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &DiagnosticsError{Diagnostics: errs}
+}
+
+// unsupported returns the error about the object, or, with Options.SkipUnsupported,
+// records it as a diagnostic and returns nil, which leaves the object as is.
+func (f *flattener) unsupported(v any, err error) error {
+	if !f.opts.SkipUnsupported {
+		return err
+	}
+
+	if f.skipped[v] {
+		return nil // already reported
+	}
+
+	f.skipped[v] = true
+
+	f.diagnostics = append(f.diagnostics, Diagnostic{
+		Severity: SeverityError,
+		Location: f.locations[v],
+		Message:  err.Error(),
+	})
+
+	return nil
+}
+
+// unusedNames warns about the locations in Options.Names that did not match an object that was moved to the components.
+func (f *flattener) unusedNames() {
+	for _, loc := range slices.Sorted(maps.Keys(f.opts.Names)) {
+		if !f.pinned[loc] {
+			f.diagnostics = append(f.diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Location: loc,
+				Message:  "no inline object to name " + f.opts.Names[loc],
+			})
+		}
+	}
+}
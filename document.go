@@ -5,23 +5,186 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
+// flattener holds the document being flattened and the options that apply.
+type flattener struct {
+	d     *openapi.Document
+	opts  Options
+	namer Namer
+
+	// known maps the kind, name and fingerprint of objects moved to the components
+	// to their component name, so that identical objects can share a component.
+	known map[string]string
+	// shared maps the kind and fingerprint of objects used more than once to the name of their shared component.
+	shared map[string]string
+	// locations maps the inline objects to their location in the document.
+	locations locations
+	// pathPrefix is the prefix that was moved from the paths to the server URLs.
+	pathPrefix string
+	// pinned contains the locations in Options.Names that were used.
+	pinned map[string]bool
+	// diagnostics lists the problems found while flattening.
+	diagnostics []Diagnostic
+	// skipped contains the objects that were left as is because they are not supported.
+	skipped map[any]bool
+	// collisions maps the kind and name of objects to the locations of all objects that ask for that name,
+	// so that Options.StableNames can tell each of them apart by its own location.
+	collisions map[string][]string
+	// canonical maps the location of an object to the first of the sorted locations of the objects
+	// that share its component, so that Options.StableNames does not depend on which of them is visited first.
+	canonical map[string]string
+	// requested, if set, collects the locations of the objects that ask for each kind and name.
+	requested map[string][]string
+	// uses, if set, collects the locations of the objects that each component replaces.
+	uses map[string][]string
+}
+
 // Document flattens an entire OpenAPI document so it contains no nested objects.
 func Document(d *openapi.Document) error {
-	moveCommonPathPrefix(d)
+	return DocumentWithOptions(d, Options{})
+}
+
+// DocumentWithOptions flattens an OpenAPI document, applying only the passes and promotion rules enabled by the options.
+func DocumentWithOptions(d *openapi.Document, opts Options) error {
+	if opts.Atomic {
+		return documentAtomic(d, opts)
+	}
+
+	f := newFlattener(d, opts)
 
-	if err := paths(d, d.Paths); err != nil {
-		return &errpath.ErrField{Field: "paths", Err: err}
+	if opts.StableNames {
+		collisions, canonical, err := nameCollisions(d, opts)
+		if err != nil {
+			return err
+		}
+
+		f.collisions, f.canonical = collisions, canonical
+	}
+
+	if err := f.flatten(); err != nil {
+		return err
 	}
 
-	// if err := webhooks(d.Webhooks); err != nil {
-	// 	return &errpath.ErrField{Field: "webhooks", Err: err}
-	// }
+	// without a report, the errors would be lost
+	if opts.Report == nil {
+		return f.errorDiagnostics()
+	}
+
+	return nil
+}
+
+func newFlattener(d *openapi.Document, opts Options) *flattener {
+	f := &flattener{
+		d:       d,
+		opts:    opts,
+		namer:   opts.Namer,
+		known:   map[string]string{},
+		shared:  map[string]string{},
+		pinned:  map[string]bool{},
+		skipped: map[any]bool{},
+	}
+
+	if f.namer == nil {
+		f.namer = DefaultNamer{}
+	}
+
+	return f
+}
+
+// flatten runs the passes that the options enable.
+func (f *flattener) flatten() error {
+	d, opts := f.d, f.opts
+
+	if opts.DeduplicateSchemas {
+		f.indexSchemas()
+	}
+
+	if opts.DeduplicateResponses {
+		f.indexResponses()
+	}
+
+	if opts.DeduplicateRequestBodies {
+		f.indexRequestBodies()
+	}
+
+	if !opts.InlineExamples {
+		f.indexExamples()
+	}
+
+	if !opts.InlineLinks {
+		f.indexLinks()
+	}
+
+	// the locations refer to the paths as given, before the common prefix is moved
+	if len(opts.Names) > 0 || opts.StableNames || opts.Report != nil || opts.SkipUnsupported {
+		locs, err := locate(d)
+		if err != nil {
+			return err
+		}
+
+		f.locations = locs
+	}
+
+	if !opts.KeepPathPrefix {
+		f.pathPrefix = moveCommonPathPrefix(d)
+		if opts.Report != nil {
+			opts.Report.PathPrefix = f.pathPrefix
+		}
+	}
+
+	if !opts.SkipPaths {
+		if err := f.paths(d.Paths); err != nil {
+			return &errpath.ErrField{Field: "paths", Err: err}
+		}
+	}
+
+	if !opts.SkipWebhooks {
+		if err := f.webhooks(d.Webhooks); err != nil {
+			return &errpath.ErrField{Field: "webhooks", Err: err}
+		}
+	}
+
+	if !opts.SkipComponents {
+		if err := f.components(d.Components); err != nil {
+			return &errpath.ErrField{Field: "components", Err: err}
+		}
+	}
+
+	if !opts.KeepOperationParameters {
+		hoisted := hoistParams(d)
+		if opts.Report != nil {
+			opts.Report.HoistedParameters = hoisted
+		}
+	}
+
+	f.unusedNames()
+	if opts.Report != nil {
+		opts.Report.Diagnostics = f.diagnostics
+	}
+
+	if opts.PruneComponents {
+		pruned, err := pruneComponents(d)
+		if err != nil {
+			return err
+		}
+
+		if opts.Report != nil {
+			opts.Report.PrunedComponents = pruned
+		}
+	}
+
+	return nil
+}
+
+// documentAtomic flattens a copy of the document and swaps it in if flattening succeeds.
+func documentAtomic(d *openapi.Document, opts Options) error {
+	c := Clone(d)
 
-	if err := components(d, d.Components); err != nil {
-		return &errpath.ErrField{Field: "components", Err: err}
+	opts.Atomic = false
+	if err := DocumentWithOptions(c, opts); err != nil {
+		return err
 	}
 
-	hoistParams(d)
+	*d = *c
 
 	return nil
 }
@@ -0,0 +1,16 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+)
+
+// encoding moves the headers of a part of a multipart body to the components,
+// where they are shared with identical response headers.
+func (f *flattener) encoding(e *openapi.Encoding) error {
+	if err := f.headerRefs(e.Headers); err != nil {
+		return &errpath.ErrField{Field: "headers", Err: err}
+	}
+
+	return nil
+}
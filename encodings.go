@@ -0,0 +1,16 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+)
+
+func (f *flattener) encodings(es openapi.Encodings) error {
+	for prop, e := range es.ByIndex() {
+		if err := f.encoding(e); err != nil {
+			return &errpath.ErrKey{Key: prop, Err: err}
+		}
+	}
+
+	return nil
+}
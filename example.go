@@ -0,0 +1,42 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/openapi"
+	"github.com/ettle/strcase"
+)
+
+// nameExample returns a human-readable name for the example, e.g. "CreatePetJSONRequestBodyDogExample".
+func nameExample(parentName, key string) string {
+	return strcase.ToGoPascal(parentName + " " + key + " Example")
+}
+
+// exampleName names the example with the namer, if it is an ExampleNamer, or like DefaultNamer.
+func (f *flattener) exampleName(parentName, key string) string {
+	if n, ok := f.namer.(ExampleNamer); ok {
+		return n.Example(parentName, key)
+	}
+
+	return nameExample(parentName, key)
+}
+
+func (f *flattener) exampleRef(ex *openapi.ExampleRef, name string) {
+	if ex.Ref != nil {
+		return
+	}
+
+	// reuse an identical example that was already moved to the components
+	key := "examples/" + fingerprint(ex.Value)
+	if exName, ok := f.known[key]; ok {
+		f.promoted(ex.Value, "examples", exName, "identical example")
+		ex.Value = f.d.Components.Examples[exName].Value
+		ex.Ref = newRef("examples", exName)
+		return
+	}
+
+	// reference the example in the components
+	exName := componentName(f, f.d.Components.Examples, ex.Value, f.pinnedName(ex.Value, name))
+	f.d.Components.Examples.Set(exName, &openapi.ExampleRef{Value: ex.Value})
+	ex.Ref = newRef("examples", exName)
+	f.promoted(ex.Value, "examples", exName, "inline example")
+	f.known[key] = exName
+}
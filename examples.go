@@ -0,0 +1,31 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/openapi"
+)
+
+// exampleRefs moves the inline examples of the named media type, parameter or header to the components.
+func (f *flattener) exampleRefs(exs openapi.Examples, name string) {
+	if f.opts.InlineExamples {
+		return
+	}
+
+	for key, ex := range exs.ByIndex() {
+		f.exampleRef(ex, f.exampleName(name, key))
+	}
+}
+
+// indexExamples remembers the examples that are already in the components,
+// so that identical inline examples can reference them.
+func (f *flattener) indexExamples() {
+	for name, ex := range f.d.Components.Examples.ByIndex() {
+		if ex.Ref != nil {
+			continue
+		}
+
+		key := "examples/" + fingerprint(ex.Value)
+		if _, ok := f.known[key]; !ok {
+			f.known[key] = name
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package flatten
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MarkRosemaker/openapi"
+)
+
+var (
+	typeReference = reflect.TypeFor[*openapi.Reference]()
+	typeRegexp    = reflect.TypeFor[*regexp.Regexp]()
+	typeNumber    = reflect.TypeFor[json.Number]()
+	typeSchema    = reflect.TypeFor[openapi.Schema]()
+)
+
+// fingerprint returns a canonical serialization of the value,
+// so that structurally identical objects have the same fingerprint.
+//
+// Map keys are sorted, so the order of properties does not matter,
+// references are represented by their identifier only,
+// and raw JSON values are compared regardless of their formatting, with numbers as written.
+func fingerprint(v any) string {
+	fp := &fingerprinter{}
+	fp.write(reflect.ValueOf(v))
+	return fp.String()
+}
+
+// schemaFingerprint returns the fingerprint of the schema with the references to other schemas
+// replaced by the schemas they reference, so that it is the same before and after nested schemas
+// are moved to the components. A reference back to a schema that contains it is kept as is.
+func schemaFingerprint(s *openapi.Schema) string {
+	fp := &fingerprinter{expanding: map[*openapi.Schema]bool{}}
+	fp.write(reflect.ValueOf(s))
+	return fp.String()
+}
+
+// fingerprinter writes the fingerprint of a value.
+type fingerprinter struct {
+	strings.Builder
+
+	// expanding, if set, contains the schemas being written, whose references are followed.
+	expanding map[*openapi.Schema]bool
+}
+
+func (b *fingerprinter) write(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		b.WriteString("nil")
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+
+		if v.Type() == typeRegexp {
+			fmt.Fprintf(b, "%q", v.Interface().(*regexp.Regexp).String())
+			return
+		}
+
+		b.write(v.Elem())
+	case reflect.Struct:
+		if b.expanding != nil && v.Type() == typeSchema {
+			s := v.Addr().Interface().(*openapi.Schema)
+			b.expanding[s] = true
+			defer delete(b.expanding, s)
+		}
+
+		// a reference or value only needs the reference, if set
+		if ref := v.FieldByName("Ref"); ref.IsValid() &&
+			ref.Type() == typeReference && !ref.IsNil() {
+			if s, ok := v.FieldByName("Value").Interface().(*openapi.Schema); ok &&
+				b.expanding != nil && s != nil && !b.expanding[s] {
+				b.write(reflect.ValueOf(s))
+				return
+			}
+
+			b.write(ref)
+			return
+		}
+
+		b.WriteByte('{')
+		for i := range v.NumField() {
+			if !v.Type().Field(i).IsExported() {
+				continue // e.g. the index for the ordering
+			}
+
+			b.WriteString(v.Type().Field(i).Name)
+			b.WriteByte(':')
+			b.write(v.Field(i))
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(a.String(), b.String())
+		})
+
+		b.WriteByte('{')
+		for _, k := range keys {
+			fmt.Fprintf(b, "%q:", k.String())
+			b.write(v.MapIndex(k))
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// raw JSON, e.g. examples and extensions, regardless of its formatting
+			// numbers are kept as written, so that large integers don't collide as float64
+			dec := json.NewDecoder(bytes.NewReader(v.Bytes()))
+			dec.UseNumber()
+
+			var val any
+			if err := dec.Decode(&val); err != nil {
+				fmt.Fprintf(b, "%q", v.Bytes())
+				return
+			}
+
+			b.write(reflect.ValueOf(val))
+			return
+		}
+
+		b.WriteByte('[')
+		for i := range v.Len() {
+			b.write(v.Index(i))
+			b.WriteByte(',')
+		}
+		b.WriteByte(']')
+	case reflect.String:
+		if v.Type() == typeNumber {
+			b.WriteString(v.String()) // unquoted, unlike a string
+			return
+		}
+
+		fmt.Fprintf(b, "%q", v.String())
+	default:
+		fmt.Fprint(b, v.Interface())
+	}
+}
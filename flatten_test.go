@@ -3,8 +3,11 @@ package flatten_test
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/MarkRosemaker/openapi"
@@ -22,16 +25,7 @@ func TestFlatten_TestData(t *testing.T) {
 
 	for _, tc := range entries {
 		t.Run(tc.Name(), func(t *testing.T) {
-			f, err := testdata.Open(filepath.Join("testdata", tc.Name(), "openapi.json"))
-			if err != nil {
-				t.Fatal(err)
-			}
-			defer f.Close() //nolint
-
-			doc, err := openapi.LoadFromReader(f)
-			if err != nil {
-				t.Fatal(err)
-			}
+			doc := loadTestData(t, tc.Name())
 
 			for it := range 3 {
 				t.Run(fmt.Sprintf("iteration %d", it+1), func(t *testing.T) {
@@ -60,6 +54,38 @@ func TestFlatten_TestData(t *testing.T) {
 	}
 }
 
+func TestFlatten_Reload(t *testing.T) {
+	entries, err := testdata.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range entries {
+		t.Run(tc.Name(), func(t *testing.T) {
+			doc := loadTestData(t, tc.Name())
+
+			if err := flatten.Document(doc); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := doc.ToJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// every reference the flattener added must be resolved when the document is loaded again
+			reloaded, err := openapi.LoadFromDataJSON(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reloaded.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 // compareBytes prints a compact diff of two byte slices
 func compareBytes(t *testing.T, expected, actual []byte) {
 	t.Helper()
@@ -83,3 +109,925 @@ func compareBytes(t *testing.T, expected, actual []byte) {
 			return fmt.Sprintf("0x%02x vs 0x%02x", expected[i], actual[i])
 		}())
 }
+
+func loadTestData(t *testing.T, name string) *openapi.Document {
+	t.Helper()
+
+	f, err := testdata.Open(filepath.Join("testdata", name, "openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint
+
+	doc, err := openapi.LoadFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestDocumentWithOptions(t *testing.T) {
+	doc := loadTestData(t, "petstore")
+
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{
+		KeepPathPrefix:   true,
+		InlineResponses:  true,
+		InlineParameters: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Paths["/v1/pets"]; !ok {
+		t.Error("expected path prefix to be kept")
+	}
+
+	if n := len(doc.Components.Responses); n != 0 {
+		t.Errorf("expected no responses in components, got %d", n)
+	}
+
+	if n := len(doc.Components.Parameters); n != 0 {
+		t.Errorf("expected no parameters in components, got %d", n)
+	}
+
+	if len(doc.Components.Schemas) == 0 {
+		t.Error("expected schemas to be moved to components")
+	}
+}
+
+func TestDocumentWithOptions_DeduplicateSchemas(t *testing.T) {
+	doc := loadTestData(t, "petstore")
+	if err := flatten.Document(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	dedup := loadTestData(t, "petstore")
+	if err := flatten.DocumentWithOptions(dedup, flatten.Options{DeduplicateSchemas: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dedup.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, all := len(dedup.Components.Schemas), len(doc.Components.Schemas); got >= all {
+		t.Errorf("expected fewer than %d schemas, got %d", all, got)
+	}
+}
+
+func TestDocumentWithOptions_DeduplicateNestedSchemas(t *testing.T) {
+	// the inline schema is identical to Thing, which has a nested enum
+	doc, err := openapi.LoadFromData([]byte(`{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/a": {
+      "get": {
+        "operationId": "GetA",
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {"kind": {"type": "string", "enum": ["a", "b"]}}
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Thing": {
+        "type": "object",
+        "properties": {"kind": {"type": "string", "enum": ["a", "b"]}}
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{DeduplicateSchemas: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := slices.Sorted(maps.Keys(doc.Components.Schemas)), []string{"Thing", "ThingKind"}; !slices.Equal(got, want) {
+		t.Errorf("got schemas %v, want %v", got, want)
+	}
+
+	s := doc.Components.Responses["GetAOkResponse"].Value.Content["application/json"].Schema
+	if s.Ref == nil || s.Ref.Identifier != "#/components/schemas/Thing" {
+		t.Errorf("expected a reference to Thing, got %+v", s.Ref)
+	}
+}
+
+func TestDocumentWithOptions_DeduplicateResponses(t *testing.T) {
+	doc := loadTestData(t, "webhooks")
+
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{DeduplicateResponses: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := doc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	newPet := doc.Webhooks["newPet"].Value
+	for method, op := range map[string]*openapi.Operation{"POST": newPet.Post, "PUT": newPet.Put} {
+		if got := op.Responses["200"].Ref.Identifier; got != "#/components/responses/OkResponse" {
+			t.Errorf("%s: expected shared response, got %q", method, got)
+		}
+	}
+}
+
+func TestDocumentWithOptions_DeduplicateResponsesCount(t *testing.T) {
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/a": {
+      "get": {
+        "operationId": "GetA",
+        "responses": {"401": {"description": "Unauthorized"}},
+        "callbacks": {
+          "onEvent": {
+            "{$request.body#/url}": {
+              "post": {"responses": {"401": {"description": "Unauthorized"}}}
+            }
+          }
+        }
+      }
+    }
+  },
+  "webhooks": {
+    "ping": {
+      "post": {"responses": {"401": {"description": "Unauthorized"}}}
+    }
+  }
+}`
+
+	for _, tc := range []struct {
+		name string
+		opts flatten.Options
+		want string
+	}{
+		{"webhooks", flatten.Options{DeduplicateResponses: true}, "UnauthorizedResponse"},
+		// neither the skipped webhook nor the callback, which is never flattened, share the response
+		{"skip webhooks", flatten.Options{DeduplicateResponses: true, SkipWebhooks: true}, "GetAUnauthorizedResponse"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := openapi.LoadFromData([]byte(spec))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := flatten.DocumentWithOptions(doc, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := slices.Collect(maps.Keys(doc.Components.Responses)); !slices.Equal(got, []string{tc.want}) {
+				t.Errorf("got responses %v, want [%s]", got, tc.want)
+			}
+		})
+	}
+}
+
+type statusCodeNamer struct{ flatten.DefaultNamer }
+
+func (statusCodeNamer) Response(opID string, code openapi.StatusCode) string {
+	return opID + "_" + string(code)
+}
+
+func TestDocumentWithOptions_Namer(t *testing.T) {
+	doc := loadTestData(t, "webhooks")
+
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{Namer: statusCodeNamer{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"CreateSubscription_201", "NewPet_200", "PetAdoptedEvent_200", "PetAdoptedEvent_400"} {
+		if _, ok := doc.Components.Responses[name]; !ok {
+			t.Errorf("expected response %q in components", name)
+		}
+	}
+}
+
+// minimalNamer implements Namer only, without the optional ExampleNamer and LinkNamer.
+type minimalNamer struct{ flatten.Namer }
+
+// linkKeyNamer names links after their key.
+type linkKeyNamer struct{ minimalNamer }
+
+func (linkKeyNamer) Link(key, _ string) string { return key }
+
+func TestDocumentWithOptions_OptionalNamers(t *testing.T) {
+	// without ExampleNamer, examples are named like DefaultNamer does
+	examples := loadTestData(t, "examples")
+	if err := flatten.DocumentWithOptions(examples, flatten.Options{Namer: minimalNamer{flatten.DefaultNamer{}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := examples.Components.Examples["CreatePetJSONRequestBodyDogExample"]; !ok {
+		t.Errorf("unexpected examples in components: %v", slices.Sorted(maps.Keys(examples.Components.Examples)))
+	}
+
+	// with LinkNamer, the namer names the links
+	links := loadTestData(t, "links")
+	if err := flatten.DocumentWithOptions(links, flatten.Options{Namer: linkKeyNamer{minimalNamer{flatten.DefaultNamer{}}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := slices.Sorted(maps.Keys(links.Components.Links)); !slices.Equal(got, []string{"self", "update"}) {
+		t.Errorf("unexpected links in components: %v", got)
+	}
+}
+
+func TestDocumentWithOptions_Names(t *testing.T) {
+	doc := loadTestData(t, "webhooks")
+
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{Names: map[string]string{
+		`webhooks["newPet"].POST.requestBody`:                                    "NewPetEvent",
+		`webhooks["newPet"].POST.requestBody.content["application/json"].schema`: "Pet",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	rb := doc.Webhooks["newPet"].Value.Post.RequestBody
+	if got := rb.Ref.Identifier; got != "#/components/requestBodies/NewPetEvent" {
+		t.Fatalf("request body ref = %q", got)
+	}
+
+	if got := rb.Value.Content["application/json"].Schema.Ref.Identifier; got != "#/components/schemas/Pet" {
+		t.Fatalf("schema ref = %q", got)
+	}
+
+	// the names of nested objects derive from the pinned name
+	if _, ok := doc.Components.Schemas["PetStatus"]; !ok {
+		t.Errorf("expected schema %q in components", "PetStatus")
+	}
+
+	// locations refer to the paths before their common prefix is moved
+	petstore := loadTestData(t, "petstore")
+	if err := flatten.DocumentWithOptions(petstore, flatten.Options{Names: map[string]string{
+		`paths["/v1/pets"].GET.responses["200"]`: "PetList",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := petstore.Paths["/"].Get.Responses["200"].Ref.Identifier; got != "#/components/responses/PetList" {
+		t.Errorf("response ref = %q", got)
+	}
+
+	// a pinned name that is taken is an error
+	taken := loadTestData(t, "webhooks")
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(taken, flatten.Options{Report: report, Names: map[string]string{
+		`webhooks["newPet"].POST.requestBody`: "PetEvent",
+		`webhooks["newPet"].PUT.requestBody`:  "PetEvent",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := flatten.Diagnostic{
+		Severity: flatten.SeverityError,
+		Location: `webhooks["newPet"].POST.requestBody`,
+		Message:  "pinned name PetEvent is taken, named it PetEvent2",
+	}
+	if !slices.Contains(report.Diagnostics, want) {
+		t.Errorf("expected diagnostic %v, got %v", want, report.Diagnostics)
+	}
+}
+
+func TestDocumentWithOptions_StableNames(t *testing.T) {
+	doc := loadTestData(t, "go-pkgsite")
+
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{StableNames: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// every parameter that asks for a taken name is told apart by its location, even the first one
+	for _, name := range []string{"filterSearch", "filterVulnsPath", "limitPackagesPath", "q"} {
+		if _, ok := doc.Components.Parameters[name]; !ok {
+			t.Errorf("expected parameter %q in components", name)
+		}
+	}
+
+	for name := range doc.Components.Parameters {
+		if name == "filter" || name[len(name)-1] >= '2' && name[len(name)-1] <= '9' {
+			t.Errorf("unexpected parameter %q in components", name)
+		}
+	}
+
+	// an operation inserted before the others does not rename their parameters
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {%s
+    "/search": {
+      "get": {
+        "parameters": [{"name": "filter", "in": "query", "description": "search", "schema": {"type": "string"}}],
+        "responses": {"204": {"description": "No Content"}}
+      }
+    },
+    "/vulns": {
+      "get": {
+        "parameters": [{"name": "filter", "in": "query", "description": "vulns", "schema": {"type": "string"}}],
+        "responses": {"204": {"description": "No Content"}}
+      }
+    }
+  }
+}`
+
+	const earlier = `
+    "/alerts": {
+      "get": {
+        "parameters": [{"name": "filter", "in": "query", "description": "alerts", "schema": {"type": "string"}}],
+        "responses": {"204": {"description": "No Content"}}
+      }
+    },`
+
+	var names [][]string
+	for _, paths := range []string{"", earlier} {
+		doc, err := openapi.LoadFromData([]byte(fmt.Sprintf(spec, paths)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := flatten.DocumentWithOptions(doc, flatten.Options{StableNames: true, KeepPathPrefix: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, slices.Sorted(maps.Keys(doc.Components.Parameters)))
+	}
+
+	if want := []string{"filterSearch", "filterVulns"}; !slices.Equal(names[0], want) {
+		t.Errorf("expected %v, got %v", want, names[0])
+	}
+
+	if want := []string{"filterAlerts", "filterSearch", "filterVulns"}; !slices.Equal(names[1], want) {
+		t.Errorf("expected %v, got %v", want, names[1])
+	}
+}
+
+func TestDocumentWithOptions_Report(t *testing.T) {
+	doc := loadTestData(t, "petstore")
+
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{Report: report}); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.PathPrefix != "/v1/pets" {
+		t.Errorf("path prefix = %q", report.PathPrefix)
+	}
+
+	want := flatten.Promotion{
+		From:   `paths["/v1/pets"].GET.responses["200"]`,
+		To:     "#/components/responses/ListV1PetsOkResponse",
+		Reason: "inline response",
+	}
+	if !slices.Contains(report.Promotions, want) {
+		t.Errorf("expected promotion %+v in report:\n%s", want, report)
+	}
+
+	for _, p := range report.Promotions {
+		if p.From == "" {
+			t.Errorf("promotion to %s without location", p.To)
+		}
+	}
+}
+
+func TestInline(t *testing.T) {
+	doc := loadTestData(t, "petstore")
+
+	if err := flatten.Document(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flatten.Inline(doc, flatten.InlineOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(doc.Components.Schemas) + len(doc.Components.Responses) + len(doc.Components.Parameters); n != 0 {
+		t.Errorf("expected all components to be inlined, %d are left", n)
+	}
+
+	if err := doc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInline_Recursive(t *testing.T) {
+	doc, err := openapi.LoadFromData([]byte(`{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/nodes": {
+      "get": {
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Node"}}}
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Node": {
+        "type": "object",
+        "properties": {
+          "children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}},
+          "label": {"$ref": "#/components/schemas/Label"}
+        }
+      },
+      "Label": {"type": "object", "properties": {"text": {"type": "string"}}}
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flatten.Inline(doc, flatten.InlineOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Components.Schemas["Node"]; !ok {
+		t.Error("expected recursive schema Node to stay in the components")
+	}
+
+	if _, ok := doc.Components.Schemas["Label"]; ok {
+		t.Error("expected schema Label to be inlined")
+	}
+}
+
+func TestInline_CallbackRefs(t *testing.T) {
+	doc, err := openapi.LoadFromData([]byte(callbackSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flatten.Inline(doc, flatten.InlineOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Components.Schemas) != 0 {
+		t.Errorf("expected schema Event, used once in a callback, to be inlined, got %v", slices.Sorted(maps.Keys(doc.Components.Schemas)))
+	}
+
+	cb := doc.Paths["/subscriptions"].Post.Callbacks["onEvent"]["{$request.body#/url}"]
+	s := cb.Value.Post.RequestBody.Value.Content["application/json"].Schema
+	if s.Ref != nil || s.Value == nil || s.Value.Properties["id"] == nil {
+		t.Errorf("expected the schema in the callback to be inlined, got %+v", s)
+	}
+}
+
+func TestDocumentWithOptions_PruneComponents(t *testing.T) {
+	doc := loadTestData(t, "go-pkgsite")
+
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{PruneComponents: true, Report: report}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Error only references itself
+	if _, ok := doc.Components.Schemas["Error"]; ok {
+		t.Error("expected unreferenced schema Error to be removed")
+	}
+
+	if !slices.Contains(report.PrunedComponents, "#/components/schemas/Error") {
+		t.Errorf("expected Error in pruned components, got %v", report.PrunedComponents)
+	}
+
+	for name := range doc.Components.Parameters {
+		if slices.Contains(report.PrunedComponents, "#/components/parameters/"+name) {
+			t.Errorf("parameter %q is reported as pruned but still in the components", name)
+		}
+	}
+
+	if len(doc.Components.Parameters) == 0 {
+		t.Error("expected referenced parameters to stay in the components")
+	}
+}
+
+// callbackSpec has a reference in an operation callback, which the loader leaves unresolved.
+const callbackSpec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/subscriptions": {
+      "post": {
+        "operationId": "Subscribe",
+        "callbacks": {
+          "onEvent": {
+            "{$request.body#/url}": {
+              "post": {
+                "requestBody": {
+                  "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Event"}}}
+                },
+                "responses": {"204": {"description": "No Content"}}
+              }
+            }
+          }
+        },
+        "responses": {"201": {"description": "Created"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Event": {"type": "object", "properties": {"id": {"type": "string"}}}
+    }
+  }
+}`
+
+func TestDocumentWithOptions_PruneCallbackRefs(t *testing.T) {
+	doc, err := openapi.LoadFromData([]byte(callbackSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Components.Schemas["Unused"] = &openapi.Schema{Type: openapi.TypeString}
+
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{PruneComponents: true, Report: report}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Components.Schemas["Event"]; !ok {
+		t.Error("expected schema Event, referenced in a callback, to stay in the components")
+	}
+
+	if want := []string{"#/components/schemas/Unused"}; !slices.Equal(report.PrunedComponents, want) {
+		t.Errorf("got pruned components %v, want %v", report.PrunedComponents, want)
+	}
+}
+
+func TestDocumentWithOptions_InlineTypeless(t *testing.T) {
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/events": {
+      "get": {
+        "operationId": "ListEvents",
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "payload": {},
+                    "meta": {
+                      "properties": {
+                        "owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+	for _, tc := range []struct {
+		inlineTypeless bool
+		want           []string
+	}{
+		{false, []string{"ListEventsOkJSONResponse", "ListEventsOkJSONResponseMeta", "ListEventsOkJSONResponseMetaOwner"}},
+		{true, []string{"ListEventsOkJSONResponse", "ListEventsOkJSONResponseMetaOwner"}},
+	} {
+		doc, err := openapi.LoadFromData([]byte(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := flatten.DocumentWithOptions(doc, flatten.Options{InlineTypeless: tc.inlineTypeless}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := slices.Sorted(maps.Keys(doc.Components.Schemas)); !slices.Equal(got, tc.want) {
+			t.Errorf("InlineTypeless %v: got schemas %v, want %v", tc.inlineTypeless, got, tc.want)
+		}
+	}
+}
+
+func TestDocumentWithOptions_SkipUnsupported(t *testing.T) {
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/files": {
+      "post": {
+        "operationId": "UploadFile",
+        "requestBody": {
+          "content": {
+            "application/json": {
+              "schema": {
+                "type": "object",
+                "properties": {
+                  "file": {"type": "file"},
+                  "owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+                }
+              }
+            }
+          }
+        },
+        "responses": {"204": {"description": "No Content"}}
+      }
+    }
+  }
+}`
+
+	doc, err := openapi.LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flatten.Document(doc); err == nil {
+		t.Fatal("expected an error for the unsupported schema")
+	}
+
+	doc, err = openapi.LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{
+		SkipUnsupported: true,
+		Report:          report,
+		Names:           map[string]string{`paths["/files"].DELETE.requestBody`: "DeleteFileRequestBody"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Components.Schemas["UploadFileJSONRequestBodyOwner"]; !ok {
+		t.Errorf("expected the supported parts to be flattened, got schemas %v", slices.Sorted(maps.Keys(doc.Components.Schemas)))
+	}
+
+	want := []flatten.Diagnostic{
+		{
+			Severity: flatten.SeverityError,
+			Location: `paths["/files"].POST.requestBody.content["application/json"].schema.properties["file"]`,
+			Message:  `unimplemented schema ref type "file"`,
+		},
+		{
+			Severity: flatten.SeverityWarning,
+			Location: `paths["/files"].DELETE.requestBody`,
+			Message:  "no inline object to name DeleteFileRequestBody",
+		},
+	}
+	if !slices.Equal(report.Diagnostics, want) {
+		t.Errorf("got diagnostics:\n%v\nwant:\n%v", report.Diagnostics, want)
+	}
+
+	// without a report, the errors are returned
+	doc, err = openapi.LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diagErr *flatten.DiagnosticsError
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{
+		SkipUnsupported: true,
+		Names:           map[string]string{`paths["/files"].DELETE.requestBody`: "DeleteFileRequestBody"},
+	}); !errors.As(err, &diagErr) {
+		t.Fatalf("expected a DiagnosticsError, got %v", err)
+	}
+
+	if !slices.Equal(diagErr.Diagnostics, want[:1]) {
+		t.Errorf("got diagnostics:\n%v\nwant:\n%v", diagErr.Diagnostics, want[:1])
+	}
+}
+
+func TestClone(t *testing.T) {
+	doc := loadTestData(t, "webhooks")
+	if err := flatten.Document(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := doc.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := flatten.Clone(doc)
+
+	got, err := c.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareBytes(t, want, got)
+
+	// a resolved reference shares its value with the component, like in the original
+	rb := c.Webhooks["newPet"].Value.Post.RequestBody
+	if rb.Value == nil || rb.Value != c.Components.RequestBodies[rb.Ref.Identifier[len("#/components/requestBodies/"):]].Value {
+		t.Error("expected the reference to share the value of the component")
+	}
+
+	if rb.Value == doc.Webhooks["newPet"].Value.Post.RequestBody.Value {
+		t.Error("expected a copy of the request body")
+	}
+
+	// changing the copy leaves the original as is
+	if err := flatten.Inline(c, flatten.InlineOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := doc.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareBytes(t, want, after)
+}
+
+func TestDocumentWithOptions_Atomic(t *testing.T) {
+	// the components pass fails after the paths have been flattened
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/pets": {
+      "post": {
+        "operationId": "CreatePet",
+        "requestBody": {
+          "content": {
+            "application/json": {
+              "schema": {"type": "object", "properties": {"name": {"type": "string"}}}
+            }
+          }
+        },
+        "responses": {"204": {"description": "No Content"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Upload": {"type": "file"}
+    }
+  }
+}`
+
+	for _, atomic := range []bool{false, true} {
+		doc, err := openapi.LoadFromData([]byte(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		before, err := doc.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := flatten.DocumentWithOptions(doc, flatten.Options{Atomic: atomic}); err == nil {
+			t.Fatal("expected an error for the unsupported schema")
+		}
+
+		after, err := doc.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if unchanged := bytes.Equal(before, after); unchanged != atomic {
+			t.Errorf("Atomic %v: document unchanged = %v", atomic, unchanged)
+		}
+	}
+}
+
+func TestDocumentWithOptions_Examples(t *testing.T) {
+	doc := loadTestData(t, "examples")
+	if err := flatten.Document(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// the example of the response is identical to the one of the request body
+	rsp := doc.Components.Responses["CreatePetCreatedResponse"].Value
+	if got := rsp.Content["application/json"].Examples["dog"].Ref.Identifier; got != "#/components/examples/CreatePetJSONRequestBodyDogExample" {
+		t.Errorf("expected shared example, got %q", got)
+	}
+
+	if n := len(doc.Components.Examples); n != 4 {
+		t.Errorf("expected 4 examples in components, got %d", n)
+	}
+
+	inline := loadTestData(t, "examples")
+	if err := flatten.DocumentWithOptions(inline, flatten.Options{InlineExamples: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(inline.Components.Examples); n != 0 {
+		t.Errorf("expected no examples in components, got %d", n)
+	}
+}
+
+func TestDocumentWithOptions_ExamplesLargeIntegers(t *testing.T) {
+	// the integers differ beyond the precision of a float64, and the string only in its type
+	const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "API", "version": "0.0.1"},
+  "paths": {
+    "/pets": {
+      "post": {
+        "operationId": "CreatePet",
+        "requestBody": {
+          "content": {
+            "application/json": {
+              "schema": {"type": "object", "properties": {"id": {"type": "integer"}}},
+              "examples": {
+                "a": {"value": {"id": 9007199254740993}},
+                "b": {"value": {"id": 9007199254740992}},
+                "c": {"value": {"id": "9007199254740992"}}
+              }
+            }
+          }
+        },
+        "responses": {"204": {"description": "No Content"}}
+      }
+    }
+  }
+}`
+
+	doc, err := openapi.LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flatten.Document(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(doc.Components.Examples); n != 3 {
+		t.Errorf("expected 3 examples in components, got %v", slices.Sorted(maps.Keys(doc.Components.Examples)))
+	}
+}
+
+func TestDocumentWithOptions_Encoding(t *testing.T) {
+	doc := loadTestData(t, "multipart")
+
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{PruneComponents: true, Report: report}); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := doc.Components.RequestBodies["UploadPhotoRequestBody"].Value.Content["multipart/form-data"]
+	if got := mt.Encoding["photo"].Headers["X-Photo-Kind"].Ref.Identifier; got != "#/components/headers/XPhotoKindHeader" {
+		t.Errorf("expected the encoding header in the components, got %q", got)
+	}
+
+	// the header is only referenced by the encoding
+	if _, ok := doc.Components.Headers["XPhotoKindHeader"]; !ok {
+		t.Errorf("expected XPhotoKindHeader to be kept, pruned %v", report.PrunedComponents)
+	}
+
+	// the enum of the header is moved to the components and referenced with allOf
+	hdr := doc.Components.Headers["XPhotoKindHeader"].Value
+	if allOf := hdr.Schema.AllOf; len(allOf) != 1 || allOf[0].Ref == nil || allOf[0].Ref.Identifier != "#/components/schemas/XPhotoKind" {
+		t.Errorf("expected the header schema to reference XPhotoKind, got %+v", hdr.Schema)
+	}
+
+	if _, ok := doc.Components.Schemas["XPhotoKind"]; !ok {
+		t.Errorf("expected XPhotoKind to be kept, pruned %v", report.PrunedComponents)
+	}
+}
+
+func TestDocumentWithOptions_Links(t *testing.T) {
+	doc := loadTestData(t, "links")
+
+	report := &flatten.Report{}
+	if err := flatten.DocumentWithOptions(doc, flatten.Options{Report: report}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the three identical self links share a component
+	if got := slices.Sorted(maps.Keys(doc.Components.Links)); !slices.Equal(got, []string{"SelfGetUserLink", "UpdateUserLink"}) {
+		t.Errorf("unexpected links in components: %v", got)
+	}
+
+	identical := 0
+	for _, p := range report.Promotions {
+		if p.Reason == "identical link" {
+			identical++
+		}
+	}
+
+	if identical != 2 {
+		t.Errorf("expected 2 identical links in the report, got %d", identical)
+	}
+}
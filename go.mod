@@ -5,20 +5,20 @@ go 1.26.3
 require (
 	github.com/MarkRosemaker/errpath v0.0.0-20260425165607-bbd4959d04d9
 	github.com/MarkRosemaker/fsutil v0.0.0-20260608162112-df3f6c7a8ea4
+	github.com/MarkRosemaker/json2yaml v0.0.0-20260507220148-d6cc0d01bff0
 	github.com/MarkRosemaker/openapi v0.0.0-20260611220347-8831c3657808
+	github.com/MarkRosemaker/yaml v0.0.0-20260508005758-fe21a538b084
 	github.com/ettle/strcase v0.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	cloud.google.com/go v0.123.0 // indirect
-	github.com/MarkRosemaker/json2yaml v0.0.0-20260507220148-d6cc0d01bff0 // indirect
 	github.com/MarkRosemaker/jsonutil v0.0.0-20260504210623-75122b64cb24 // indirect
 	github.com/MarkRosemaker/ordmap v0.0.0-20260611220112-724580dd2bee // indirect
-	github.com/MarkRosemaker/yaml v0.0.0-20260508005758-fe21a538b084 // indirect
 	github.com/MarkRosemaker/yaml2json v0.0.0-20260507220136-7748efc522b2 // indirect
 	github.com/go-api-libs/types v0.0.0-20251210072721-82754f56609d // indirect
 	github.com/spf13/afero v1.15.0 // indirect
 	golang.org/x/exp v0.0.0-20260611194520-c48552f49976 // indirect
 	golang.org/x/text v0.38.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,65 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+	"github.com/ettle/strcase"
+)
+
+// nameHeader returns a human-readable name for the header, e.g. "XRateLimitRemainingHeader".
+func nameHeader(name string) string {
+	return strcase.ToGoPascal(name + " Header")
+}
+
+func (f *flattener) headerRef(h *openapi.HeaderRef, name string) error {
+	if h.Ref != nil {
+		return nil
+	}
+
+	if f.opts.InlineHeaders {
+		return f.header(h.Value, name)
+	}
+
+	// reuse a structurally identical header that was already moved to the components
+	key := "headers/" + name + "/" + fingerprint(h.Value)
+	if hdrName, ok := f.known[key]; ok {
+		f.promoted(h.Value, "headers", hdrName, "identical header")
+		h.Value = f.d.Components.Headers[hdrName].Value
+		h.Ref = newRef("headers", hdrName)
+		return nil
+	}
+
+	// reference the header in the components
+	hdrName := componentName(f, f.d.Components.Headers, h.Value, f.pinnedName(h.Value, f.namer.Header(name)))
+	f.d.Components.Headers.Set(hdrName, &openapi.HeaderRef{Value: h.Value})
+	h.Ref = newRef("headers", hdrName)
+	f.promoted(h.Value, "headers", hdrName, "inline header")
+	f.known[key] = hdrName
+
+	return f.header(h.Value, name)
+}
+
+func (f *flattener) header(h *openapi.Header, name string) error {
+	hdrName := f.namer.Schema(name)
+
+	if h.Schema != nil {
+		// the schema of a header can not be a reference in the openapi package,
+		// so a schema moved to the components is referenced with allOf
+		s := &openapi.SchemaRef{Value: h.Schema}
+		if err := f.schemaRef(s, hdrName, moveIfNecessary); err != nil {
+			return &errpath.ErrField{Field: "schema", Err: err}
+		}
+
+		if s.Ref != nil {
+			h.Schema = &openapi.Schema{Type: s.Value.Type, AllOf: openapi.SchemaRefList{s}}
+		}
+	}
+
+	if err := f.content(h.Content, hdrName, "Header", moveIfNecessary); err != nil {
+		return &errpath.ErrField{Field: "content", Err: err}
+	}
+
+	f.exampleRefs(h.Examples, hdrName)
+
+	return nil
+}
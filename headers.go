@@ -0,0 +1,29 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+)
+
+func (f *flattener) headerRefs(hs openapi.Headers) error {
+	for name, h := range hs.ByIndex() {
+		if err := f.headerRef(h, name); err != nil {
+			return &errpath.ErrKey{Key: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (f *flattener) headers(hs openapi.Headers) error {
+	for name, h := range hs.ByIndex() {
+		// NOTE: We are *not* calling headerRef here,
+		// because we are calling this function from Components,
+		// where the header should already be.
+		if err := f.header(h.Value, name); err != nil {
+			return &errpath.ErrKey{Key: name, Err: err}
+		}
+	}
+
+	return nil
+}
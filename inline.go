@@ -0,0 +1,191 @@
+package flatten
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/MarkRosemaker/openapi"
+)
+
+// InlineOptions controls which components Inline puts back where they are used.
+type InlineOptions struct {
+	// All inlines every component that is referenced, instead of only those referenced exactly once.
+	All bool
+}
+
+// inliner holds the options of Inline and the number of references to each component.
+type inliner struct {
+	opts InlineOptions
+
+	// uses maps the kind and name of components, e.g. "schemas/Pet", to the number of references to them.
+	uses map[string]int
+	// recursive contains the kind and name of schemas that reference themselves, directly or indirectly.
+	recursive map[string]bool
+	// inlined contains the kind and name of components that were inlined.
+	inlined map[string]bool
+}
+
+// Inline is the reverse of Document: it replaces references to components/schemas, responses,
+// requestBodies, parameters and headers that are used only once with the component itself
+// and removes the component. Recursive schemas are never inlined.
+// References in the callbacks of operations, which the loader leaves unresolved, are resolved by name.
+func Inline(d *openapi.Document, opts InlineOptions) error {
+	i := &inliner{
+		opts:      opts,
+		uses:      map[string]int{},
+		recursive: recursiveSchemas(d.Components.Schemas),
+		inlined:   map[string]bool{},
+	}
+
+	// count the references to each component
+	if err := (&walker{visit: i.count, lookup: &d.Components}).document(d); err != nil {
+		return err
+	}
+
+	// inline the components referenced once
+	if err := (&walker{visit: i.use, inline: true, lookup: &d.Components}).document(d); err != nil {
+		return err
+	}
+
+	// remove the components that were inlined
+	for key := range i.inlined {
+		tp, name, _ := strings.Cut(key, "/")
+		switch tp {
+		case "schemas":
+			delete(d.Components.Schemas, name)
+		case "responses":
+			delete(d.Components.Responses, name)
+		case "requestBodies":
+			delete(d.Components.RequestBodies, name)
+		case "parameters":
+			delete(d.Components.Parameters, name)
+		case "headers":
+			delete(d.Components.Headers, name)
+		}
+	}
+
+	return nil
+}
+
+// count counts the reference to the component.
+func (i *inliner) count(tp, name string) (bool, error) {
+	i.uses[tp+"/"+name]++
+	return false, nil
+}
+
+// use tells whether to inline the reference to the component.
+func (i *inliner) use(tp, name string) (bool, error) {
+	key := tp + "/" + name
+	if i.recursive[key] || (!i.opts.All && i.uses[key] > 1) {
+		return false, nil
+	}
+
+	i.inlined[key] = true
+	return true, nil
+}
+
+// recursiveSchemas returns the kind and name of the schemas in the components
+// that reference themselves, directly or through other schemas.
+func recursiveSchemas(schemas openapi.Schemas) map[string]bool {
+	// the schemas each schema references
+	edges := map[string][]string{}
+	for name, s := range schemas.ByIndex() {
+		for ref := range schemaRefsIn(s) {
+			if target, ok := strings.CutPrefix(ref.Identifier, "#/components/schemas/"); ok {
+				edges[name] = append(edges[name], unescapePointer(target))
+			}
+		}
+	}
+
+	// find the strongly connected components with Tarjan's algorithm
+	recursive := map[string]bool{}
+	index, low := map[string]int{}, map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name], low[name] = len(index), len(index)
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, target := range edges[name] {
+			if target == name {
+				recursive["schemas/"+name] = true
+			}
+
+			if _, ok := index[target]; !ok {
+				visit(target)
+				low[name] = min(low[name], low[target])
+			} else if onStack[target] {
+				low[name] = min(low[name], index[target])
+			}
+		}
+
+		if low[name] != index[name] {
+			return
+		}
+
+		// pop the strongly connected component
+		n := slices.Index(stack, name)
+		scc := stack[n:]
+		stack = stack[:n]
+		for _, member := range scc {
+			onStack[member] = false
+			if len(scc) > 1 {
+				recursive["schemas/"+member] = true
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(edges)) {
+		if _, ok := index[name]; !ok {
+			visit(name)
+		}
+	}
+
+	return recursive
+}
+
+// schemaRefsIn yields the references in the schema, without following them.
+func schemaRefsIn(s *openapi.Schema) iter.Seq[*openapi.Reference] {
+	return func(yield func(*openapi.Reference) bool) {
+		var walk func(s *openapi.SchemaRef) bool
+		walk = func(s *openapi.SchemaRef) bool {
+			if s == nil {
+				return true
+			}
+
+			if s.Ref != nil {
+				return yield(s.Ref)
+			}
+
+			return walkSchema(s.Value, walk)
+		}
+
+		walkSchema(s, walk)
+	}
+}
+
+// walkSchema calls walk for each schema the schema contains, until walk returns false.
+func walkSchema(s *openapi.Schema, walk func(*openapi.SchemaRef) bool) bool {
+	for _, s := range s.AllOf {
+		if !walk(s) {
+			return false
+		}
+	}
+
+	if !walk(s.Items) {
+		return false
+	}
+
+	for _, s := range s.Properties.ByIndex() {
+		if !walk(s) {
+			return false
+		}
+	}
+
+	return walk(s.AdditionalProperties)
+}
@@ -0,0 +1,50 @@
+package flatten
+
+import (
+	"strings"
+
+	"github.com/MarkRosemaker/openapi"
+	"github.com/ettle/strcase"
+)
+
+// nameLink returns a human-readable name for the link, e.g. "OwnerGetUserLink",
+// or "UpdateUserLink" if the operation ID already starts with the key.
+func nameLink(key, opID string) string {
+	key, opID = strcase.ToGoPascal(key), strcase.ToGoPascal(opID)
+	if !strings.HasPrefix(opID, key) {
+		opID = key + opID
+	}
+
+	return opID + "Link"
+}
+
+// linkName names the link with the namer, if it is a LinkNamer, or like DefaultNamer.
+func (f *flattener) linkName(key, opID string) string {
+	if n, ok := f.namer.(LinkNamer); ok {
+		return n.Link(key, opID)
+	}
+
+	return nameLink(key, opID)
+}
+
+func (f *flattener) linkRef(l *openapi.LinkRef, key string) {
+	if l.Ref != nil {
+		return
+	}
+
+	// reuse an identical link that was already moved to the components
+	fp := "links/" + fingerprint(l.Value)
+	if linkName, ok := f.known[fp]; ok {
+		f.promoted(l.Value, "links", linkName, "identical link")
+		l.Value = f.d.Components.Links[linkName].Value
+		l.Ref = newRef("links", linkName)
+		return
+	}
+
+	// reference the link in the components
+	linkName := componentName(f, f.d.Components.Links, l.Value, f.pinnedName(l.Value, f.linkName(key, l.Value.OperationID)))
+	f.d.Components.Links.Set(linkName, &openapi.LinkRef{Value: l.Value})
+	l.Ref = newRef("links", linkName)
+	f.promoted(l.Value, "links", linkName, "inline link")
+	f.known[fp] = linkName
+}
@@ -0,0 +1,31 @@
+package flatten
+
+import (
+	"github.com/MarkRosemaker/openapi"
+)
+
+// linkRefs moves the inline links of a response to the components.
+func (f *flattener) linkRefs(ls openapi.Links) {
+	if f.opts.InlineLinks {
+		return
+	}
+
+	for key, l := range ls.ByIndex() {
+		f.linkRef(l, key)
+	}
+}
+
+// indexLinks remembers the links that are already in the components,
+// so that identical inline links can reference them.
+func (f *flattener) indexLinks() {
+	for name, l := range f.d.Components.Links.ByIndex() {
+		if l.Ref != nil {
+			continue
+		}
+
+		key := "links/" + fingerprint(l.Value)
+		if _, ok := f.known[key]; !ok {
+			f.known[key] = name
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package flatten
+
+import (
+	"fmt"
+
+	"github.com/MarkRosemaker/openapi"
+)
+
+// locations maps the inline objects of a document to their location,
+// in the same format errpath prints, e.g. `paths["/pets"].GET.requestBody`.
+type locations map[any]string
+
+// locate walks the document and records the location of every inline object.
+// An object that appears more than once keeps its first location.
+func locate(d *openapi.Document) (locations, error) {
+	l := locations{}
+
+	w := &walker{object: func(v any, loc string) {
+		if _, ok := l[v]; !ok {
+			l[v] = loc
+		}
+	}}
+
+	if err := w.document(d); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func locField(loc, field string) string { return loc + "." + field }
+func locKey(loc, key string) string     { return fmt.Sprintf("%s[%q]", loc, key) }
+func locIndex(loc string, i int) string { return fmt.Sprintf("%s[%d]", loc, i) }
+
+// pinnedName returns the name that Options.Names pins for the location of the object, or the given name.
+func (f *flattener) pinnedName(v any, name string) string {
+	loc, ok := f.locations[v]
+	if !ok {
+		return name
+	}
+
+	if pinned, ok := f.opts.Names[loc]; ok {
+		f.pinned[loc] = true
+		return pinned
+	}
+
+	return name
+}
@@ -13,6 +13,10 @@ func nameMediaRange(mr openapi.MediaRange) string {
 		return "JSON"
 	case openapi.MediaRangeHTML:
 		return "HTML"
+	case "multipart/form-data":
+		return "Multipart"
+	case "application/x-www-form-urlencoded":
+		return "Form"
 	default:
 		return "Unknown"
 	}
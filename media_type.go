@@ -18,24 +18,25 @@ func nameMediaType(rspOrReqBodyName, nameMediaRange string,
 	}, " "))
 }
 
-func mediaType(d *openapi.Document, mt *openapi.MediaType, mtName string, modeSchema mode) error {
+func (f *flattener) mediaType(mt *openapi.MediaType, mtName string, modeSchema mode) error {
+	// name the examples after the media type, even if its schema has a title
+	exName := mtName
+
 	if mt.Schema != nil {
 		if title := mt.Schema.Value.Title; title != "" {
-			mtName = strcase.ToGoPascal(title)
+			mtName = f.namer.Schema(title)
 		}
 
-		if err := schemaRef(d, mt.Schema, mtName, modeSchema); err != nil {
+		if err := f.schemaRef(mt.Schema, mtName, modeSchema); err != nil {
 			return &errpath.ErrField{Field: "schema", Err: err}
 		}
 	}
 
-	// if err := l.resolveExamples(mt.Examples); err != nil {
-	// 	return &errpath.ErrField{Field: "examples", Err: err}
-	// }
+	f.exampleRefs(mt.Examples, exName)
 
-	// if err := l.resolveEncodings(mt.Encoding); err != nil {
-	// 	return &errpath.ErrField{Field: "encoding", Err: err}
-	// }
+	if err := f.encodings(mt.Encoding); err != nil {
+		return &errpath.ErrField{Field: "encoding", Err: err}
+	}
 
 	return nil
 }
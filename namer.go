@@ -0,0 +1,115 @@
+package flatten
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/MarkRosemaker/openapi"
+	"github.com/ettle/strcase"
+)
+
+// Namer names the objects that are moved to the components.
+// Implement it to use your own casing and suffix conventions.
+type Namer interface {
+	// Response names a response of the operation with the given ID.
+	Response(opID string, code openapi.StatusCode) string
+	// RequestBody names the request body of the operation with the given ID.
+	RequestBody(opID string) string
+	// MediaType names the schema of a media type in the named parent object.
+	// The kind of the parent is "Response", "RequestBody", "Parameter" or "Header".
+	MediaType(parentName string, mr openapi.MediaRange, kind string) string
+	// Property names the schema of a property of the named object.
+	Property(objectName, property string) string
+	// Item names the items of the named array.
+	Item(arrayName string) string
+	// Value names the values of the named map, i.e. its additional properties.
+	Value(mapName string) string
+	// AllOf names the member at the given index of the named allOf.
+	AllOf(name string, i int) string
+	// Parameter names a parameter.
+	Parameter(name string) string
+	// Header names a header.
+	Header(name string) string
+	// Schema turns a title, parameter name or header name into the name of its schema.
+	Schema(name string) string
+	// Operation names an operation without operation ID, e.g. after its webhook.
+	Operation(name string) string
+}
+
+// ExampleNamer is implemented by a Namer that also names the examples moved to components/examples.
+// Examples are named like DefaultNamer does if the Namer does not implement it.
+type ExampleNamer interface {
+	// Example names the example with the given key in the named media type, parameter or header.
+	Example(parentName, key string) string
+}
+
+// LinkNamer is implemented by a Namer that also names the links moved to components/links.
+// Links are named like DefaultNamer does if the Namer does not implement it.
+type LinkNamer interface {
+	// Link names the response link with the given key to the operation with the given ID, if any.
+	Link(key, opID string) string
+}
+
+var (
+	_ Namer        = DefaultNamer{}
+	_ ExampleNamer = DefaultNamer{}
+	_ LinkNamer    = DefaultNamer{}
+)
+
+// DefaultNamer generates Go-style PascalCase names.
+type DefaultNamer struct{}
+
+// Response returns e.g. "CreatePetBadRequestResponse".
+func (DefaultNamer) Response(opID string, code openapi.StatusCode) string {
+	return nameResponse(opID, code)
+}
+
+// RequestBody returns e.g. "CreatePetRequestBody".
+func (DefaultNamer) RequestBody(opID string) string { return nameRequestBody(opID) }
+
+// MediaType returns e.g. "CreatePetBadRequestJSONResponse".
+func (DefaultNamer) MediaType(parentName string, mr openapi.MediaRange, kind string) string {
+	return nameMediaType(parentName, nameMediaRange(mr), kind)
+}
+
+// Property returns e.g. "PetOwner".
+func (DefaultNamer) Property(objectName, property string) string {
+	return strcase.ToGoPascal(fmt.Sprintf("%s %s", objectName, strings.ReplaceAll(property, "/", " ")))
+}
+
+// Item returns e.g. "PetsItem".
+func (DefaultNamer) Item(arrayName string) string { return arrayName + "Item" }
+
+// Value returns e.g. "LabelsValue".
+func (DefaultNamer) Value(mapName string) string { return mapName + "Value" }
+
+// AllOf returns e.g. "PetAllOf0".
+func (DefaultNamer) AllOf(name string, i int) string { return fmt.Sprintf("%sAllOf%d", name, i) }
+
+// Parameter returns the name as is.
+func (DefaultNamer) Parameter(name string) string { return name }
+
+// Header returns e.g. "XRateLimitRemainingHeader".
+func (DefaultNamer) Header(name string) string { return nameHeader(name) }
+
+// Example returns e.g. "CreatePetJSONRequestBodyDogExample".
+func (DefaultNamer) Example(parentName, key string) string { return nameExample(parentName, key) }
+
+// Link returns e.g. "OwnerGetUserLink", or "UpdateUserLink" for the key "update".
+func (DefaultNamer) Link(key, opID string) string { return nameLink(key, opID) }
+
+// Schema returns e.g. "XRateLimitRemaining".
+func (DefaultNamer) Schema(name string) string { return strcase.ToGoPascal(name) }
+
+// Operation returns e.g. "CreateSubscriptionOnEvent" for "CreateSubscription onEvent",
+// dropping characters that are not letters or digits, e.g. of runtime expressions.
+func (DefaultNamer) Operation(name string) string {
+	return strcase.ToGoPascal(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return ' '
+	}, name))
+}
@@ -1,26 +1,32 @@
 package flatten
 
 import (
+	"cmp"
+
 	"github.com/MarkRosemaker/errpath"
 	"github.com/MarkRosemaker/openapi"
 )
 
-func operation(d *openapi.Document, o *openapi.Operation) error {
-	if err := parameterList(d, o.Parameters); err != nil {
+// operation flattens the operation, naming the promoted objects after the operation ID or, if there is none, the given name.
+func (f *flattener) operation(o *openapi.Operation, name string) error {
+	opID := cmp.Or(o.OperationID, name)
+
+	if err := f.parameterList(o.Parameters); err != nil {
 		return &errpath.ErrField{Field: "parameters", Err: err}
 	}
 
 	if o.RequestBody != nil {
-		if err := requestBodyRef(d, o.RequestBody, nameRequestBody(o.OperationID)); err != nil {
+		if err := f.requestBodyRef(o.RequestBody, f.namer.RequestBody(opID)); err != nil {
 			return &errpath.ErrField{Field: "requestBody", Err: err}
 		}
 	}
 
-	if err := operationResponses(d, o.Responses, o.OperationID); err != nil {
+	if err := f.operationResponses(o.Responses, opID); err != nil {
 		return &errpath.ErrField{Field: "responses", Err: err}
 	}
 
-	// if err := callbacks(o.Callbacks); err != nil {
+	// NOTE: Callbacks are not flattened, since the openapi package does not resolve references in them.
+	// if err := f.callbacks(o.Callbacks); err != nil {
 	// 	return &errpath.ErrField{Field: "callbacks", Err: err}
 	// }
 
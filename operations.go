@@ -0,0 +1,72 @@
+package flatten
+
+import (
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/MarkRosemaker/openapi"
+)
+
+// operations returns all operations of the paths and webhooks, including those in their callbacks.
+func operations(d *openapi.Document) iter.Seq[*openapi.Operation] {
+	return func(yield func(*openapi.Operation) bool) {
+		for _, pi := range d.Paths.ByIndex() {
+			if !pathItemOperations(pi, yield) {
+				return
+			}
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(d.Webhooks)) {
+			if w := d.Webhooks[name]; w.Ref == nil && !pathItemOperations(w.Value, yield) {
+				return
+			}
+		}
+	}
+}
+
+// operations returns the operations that are flattened: those of the paths and webhooks, unless they are skipped.
+// The operations in callbacks are left out, since callbacks are not flattened.
+func (f *flattener) operations() iter.Seq[*openapi.Operation] {
+	return func(yield func(*openapi.Operation) bool) {
+		if !f.opts.SkipPaths {
+			for _, pi := range f.d.Paths.ByIndex() {
+				for _, op := range pi.Operations {
+					if !yield(op) {
+						return
+					}
+				}
+			}
+		}
+
+		if !f.opts.SkipWebhooks {
+			for _, name := range slices.Sorted(maps.Keys(f.d.Webhooks)) {
+				if w := f.d.Webhooks[name]; w.Ref == nil {
+					for _, op := range w.Value.Operations {
+						if !yield(op) {
+							return
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func pathItemOperations(pi *openapi.PathItem, yield func(*openapi.Operation) bool) bool {
+	for _, op := range pi.Operations {
+		if !yield(op) {
+			return false
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(op.Callbacks)) {
+			for _, cpi := range op.Callbacks[name].ByIndex() {
+				if cpi.Ref == nil && !pathItemOperations(cpi.Value, yield) {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package flatten
+
+// Options controls which passes and promotion rules DocumentWithOptions applies.
+// The zero value flattens the document exactly like Document.
+type Options struct {
+	// Atomic flattens a copy of the document and only replaces the document with it if flattening succeeds,
+	// so that the document is unchanged when an error is returned.
+	Atomic bool
+	// Namer names the objects that are moved to the components. Defaults to DefaultNamer.
+	Namer Namer
+	// Names pins the component name of the inline objects at the given locations.
+	// The keys are locations in the format errpath prints, e.g. `paths["/pets"].POST.requestBody`,
+	// with the paths as given, before their common prefix is moved.
+	Names map[string]string
+	// StableNames makes names that more than one object asks for unique with words from the location of each object,
+	// e.g. its path, method and status code, instead of appending a number in the order the objects are visited.
+	// It flattens copies of the document first to find these names.
+	StableNames bool
+	// Report, if not nil, is filled with the changes made to the document.
+	// If it is nil, diagnostics of severity error are returned as a *DiagnosticsError after flattening.
+	Report *Report
+
+	// KeepPathPrefix disables moving a path prefix shared by all paths into the server URLs.
+	KeepPathPrefix bool
+	// KeepOperationParameters disables hoisting parameters shared by all operations of a path item to the path item.
+	KeepOperationParameters bool
+	// SkipPaths skips flattening the objects under paths.
+	SkipPaths bool
+	// SkipWebhooks skips flattening the objects under webhooks.
+	SkipWebhooks bool
+	// SkipComponents skips flattening the objects that are already in the components.
+	SkipComponents bool
+
+	// InlineResponses keeps inline responses instead of moving them to components/responses.
+	InlineResponses bool
+	// InlineRequestBodies keeps inline request bodies instead of moving them to components/requestBodies.
+	InlineRequestBodies bool
+	// InlineParameters keeps inline parameters instead of moving them to components/parameters.
+	InlineParameters bool
+	// InlineHeaders keeps inline headers instead of moving them to components/headers.
+	InlineHeaders bool
+	// InlineExamples keeps inline examples instead of moving them to components/examples.
+	InlineExamples bool
+	// InlineLinks keeps inline response links instead of moving them to components/links.
+	InlineLinks bool
+	// InlineObjects keeps inline objects with properties instead of moving them to components/schemas.
+	InlineObjects bool
+	// InlineEnums keeps inline string enums instead of moving them to components/schemas.
+	InlineEnums bool
+	// InlineTypeless keeps schemas without a type inline, instead of moving them like objects
+	// if they have properties and like arrays if they have items.
+	InlineTypeless bool
+	// InlineArrays keeps inline arrays of enums or objects instead of moving them to components/schemas.
+	InlineArrays bool
+	// DeduplicateSchemas references an identical schema in components/schemas
+	// instead of moving a numbered copy (e.g. Foo2) to the components.
+	DeduplicateSchemas bool
+	// DeduplicateResponses references an identical response in components/responses
+	// instead of moving a copy to the components. Responses that several operations share
+	// are named after their status code (e.g. UnauthorizedResponse).
+	DeduplicateResponses bool
+	// DeduplicateRequestBodies references an identical request body in components/requestBodies
+	// instead of moving a copy to the components.
+	DeduplicateRequestBodies bool
+	// PruneComponents removes the schemas, responses, parameters, request bodies, headers and security schemes
+	// in the components that are not referenced, directly or indirectly, by the paths, webhooks or security requirements.
+	PruneComponents bool
+	// SkipUnsupported leaves schemas that can not be flattened inline and lists them in Report.Diagnostics,
+	// instead of returning an error for the first of them.
+	SkipUnsupported bool
+	// LenientErrorSchemas moves schemas of error responses only when necessary,
+	// like those of successful responses, instead of always.
+	LenientErrorSchemas bool
+}
@@ -3,34 +3,40 @@ package flatten
 import (
 	"github.com/MarkRosemaker/errpath"
 	"github.com/MarkRosemaker/openapi"
-	"github.com/ettle/strcase"
 )
 
-func parameterRef(d *openapi.Document, p *openapi.ParameterRef) error {
+func (f *flattener) parameterRef(p *openapi.ParameterRef) error {
 	if p.Ref != nil {
 		return nil
 	}
 
+	if f.opts.InlineParameters {
+		return f.parameter(p.Value)
+	}
+
 	// reference the parameter in the components
-	paramName := uniqueName(d.Components.Parameters, p.Value.Name)
-	d.Components.Parameters.Set(paramName, &openapi.ParameterRef{Value: p.Value})
+	paramName := componentName(f, f.d.Components.Parameters, p.Value, f.pinnedName(p.Value, f.namer.Parameter(p.Value.Name)))
+	f.d.Components.Parameters.Set(paramName, &openapi.ParameterRef{Value: p.Value})
 	p.Ref = newRef("parameters", paramName)
+	f.promoted(p.Value, "parameters", paramName, "inline parameter")
 
-	return parameter(d, p.Value)
+	return f.parameter(p.Value)
 }
 
-func parameter(d *openapi.Document, p *openapi.Parameter) error {
-	paramName := strcase.ToGoPascal(p.Name)
+func (f *flattener) parameter(p *openapi.Parameter) error {
+	paramName := f.namer.Schema(p.Name)
 
 	if p.Schema != nil {
-		if err := schema(d, p.Schema, paramName); err != nil {
+		if err := f.schema(p.Schema, paramName); err != nil {
 			return &errpath.ErrField{Field: "schema", Err: err}
 		}
 	}
 
-	if err := content(d, p.Content, paramName, "Parameter", moveIfNecessary); err != nil {
+	if err := f.content(p.Content, paramName, "Parameter", moveIfNecessary); err != nil {
 		return &errpath.ErrField{Field: "content", Err: err}
 	}
 
+	f.exampleRefs(p.Examples, paramName)
+
 	return nil
 }
@@ -5,9 +5,9 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func parameterList(d *openapi.Document, p openapi.ParameterList) error {
+func (f *flattener) parameterList(p openapi.ParameterList) error {
 	for i, param := range p {
-		if err := parameterRef(d, param); err != nil {
+		if err := f.parameterRef(param); err != nil {
 			return &errpath.ErrIndex{Index: i, Err: err}
 		}
 	}
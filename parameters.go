@@ -8,12 +8,12 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func parameters(d *openapi.Document, ps openapi.Parameters) error {
+func (f *flattener) parameters(ps openapi.Parameters) error {
 	for name, p := range ps.ByIndex() {
 		// NOTE: We are *not* calling parameterRef here,
 		// because we are calling this function from Components,
 		// where the parameter should already be.
-		if err := parameter(d, p.Value); err != nil {
+		if err := f.parameter(p.Value); err != nil {
 			return &errpath.ErrKey{Key: name, Err: err}
 		}
 	}
@@ -21,8 +21,11 @@ func parameters(d *openapi.Document, ps openapi.Parameters) error {
 	return nil
 }
 
-func hoistParams(d *openapi.Document) {
-	for _, pi := range d.Paths {
+// hoistParams moves the parameters that all operations of a path item share to the path item.
+// It returns the parameters it moved.
+func hoistParams(d *openapi.Document) []HoistedParameter {
+	var hoisted []HoistedParameter
+	for path, pi := range d.Paths.ByIndex() {
 		candidates := openapi.ParameterList{}
 		for _, op := range pi.Operations {
 			candidates = append(candidates, op.Parameters...)
@@ -36,6 +39,11 @@ func hoistParams(d *openapi.Document) {
 			if alreadyHoisted || allOpsHave(pi.Operations, candidate.Value) {
 				if !alreadyHoisted {
 					pi.Parameters = append(pi.Parameters, candidate)
+					hoisted = append(hoisted, HoistedParameter{
+						Path: string(path),
+						Name: candidate.Value.Name,
+						In:   string(candidate.Value.In),
+					})
 				}
 
 				for _, op := range pi.Operations {
@@ -46,6 +54,8 @@ func hoistParams(d *openapi.Document) {
 			}
 		}
 	}
+
+	return hoisted
 }
 
 func allOpsHave(ops iter.Seq2[string, *openapi.Operation], candidate *openapi.Parameter) bool {
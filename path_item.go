@@ -5,13 +5,22 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func pathItem(d *openapi.Document, pi *openapi.PathItem) error {
-	if err := parameterList(d, pi.Parameters); err != nil {
+func (f *flattener) pathItemRef(pi *openapi.PathItemRef, name string) error {
+	if pi.Ref != nil {
+		return nil
+	}
+
+	return f.pathItem(pi.Value, name)
+}
+
+// pathItem flattens the path item. The name is used for operations without an operation ID.
+func (f *flattener) pathItem(pi *openapi.PathItem, name string) error {
+	if err := f.parameterList(pi.Parameters); err != nil {
 		return &errpath.ErrField{Field: "parameters", Err: err}
 	}
 
 	for method, op := range pi.Operations {
-		if err := operation(d, op); err != nil {
+		if err := f.operation(op, name); err != nil {
 			return &errpath.ErrField{Field: method, Err: err}
 		}
 	}
@@ -5,9 +5,9 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func paths(d *openapi.Document, ps openapi.Paths) error {
+func (f *flattener) paths(ps openapi.Paths) error {
 	for p, pi := range ps.ByIndex() {
-		if err := pathItem(d, pi); err != nil {
+		if err := f.pathItem(pi, ""); err != nil {
 			return &errpath.ErrKey{Key: string(p), Err: err}
 		}
 	}
@@ -12,15 +12,15 @@ import (
 //
 // The check is skipped when there are fewer than two paths, or when the
 // longest common prefix is the root ("/") — i.e. there is nothing meaningful
-// to move.
-func moveCommonPathPrefix(d *openapi.Document) {
+// to move. It returns the prefix that was moved.
+func moveCommonPathPrefix(d *openapi.Document) string {
 	if len(d.Paths) < 2 {
-		return
+		return ""
 	}
 
 	prefix := commonPathPrefix(d.Paths)
 	if prefix == "" {
-		return
+		return ""
 	}
 
 	// Strip the prefix from each path key, preserving insertion order.
@@ -48,6 +48,8 @@ func moveCommonPathPrefix(d *openapi.Document) {
 	for i := range d.Servers {
 		d.Servers[i].URL = strings.TrimRight(d.Servers[i].URL, "/") + prefix
 	}
+
+	return prefix
 }
 
 // commonPathPrefix returns the longest common path prefix shared by all paths,
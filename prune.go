@@ -0,0 +1,113 @@
+package flatten
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+)
+
+// pruneComponents removes the schemas, responses, parameters, request bodies, headers and security schemes
+// in the components that can not be reached from the paths, webhooks, callbacks, path items or security requirements.
+// The references in the callbacks of operations, which the loader leaves unresolved, are resolved by name.
+// It returns references to the components it removed.
+func pruneComponents(d *openapi.Document) ([]string, error) {
+	reached := map[string]bool{}
+
+	w := &walker{lookup: &d.Components}
+	w.visit = func(tp, name string) (bool, error) {
+		key := tp + "/" + name
+		if reached[key] {
+			return false, nil
+		}
+
+		reached[key] = true
+
+		// walk the component itself, which might reference another component
+		switch tp {
+		case "schemas":
+			return true, nil
+		case "responses":
+			return false, w.responseRef(d.Components.Responses[name], locKey("components.responses", name))
+		case "parameters":
+			return false, w.parameterRef(d.Components.Parameters[name], locKey("components.parameters", name))
+		case "requestBodies":
+			return false, w.requestBodyRef(d.Components.RequestBodies[name], locKey("components.requestBodies", name))
+		case "headers":
+			return false, w.headerRef(d.Components.Headers[name], locKey("components.headers", name))
+		}
+
+		return false, nil
+	}
+
+	if err := w.paths(d.Paths, "paths"); err != nil {
+		return nil, &errpath.ErrField{Field: "paths", Err: err}
+	}
+
+	if err := w.webhooks(d.Webhooks, "webhooks"); err != nil {
+		return nil, &errpath.ErrField{Field: "webhooks", Err: err}
+	}
+
+	if err := w.callbackRefs(d.Components.Callbacks, "components.callbacks"); err != nil {
+		return nil, &errpath.ErrField{Field: "components", Err: &errpath.ErrField{Field: "callbacks", Err: err}}
+	}
+
+	if err := w.pathItems(d.Components.PathItems, "components.pathItems"); err != nil {
+		return nil, &errpath.ErrField{Field: "components", Err: &errpath.ErrField{Field: "pathItems", Err: err}}
+	}
+
+	// the security schemes of the security requirements
+	security := slices.Clone(d.Security)
+	collect := func(op *openapi.Operation) bool {
+		security = append(security, op.Security...)
+		return true
+	}
+
+	operations(d)(collect)
+
+	for _, c := range d.Components.Callbacks {
+		if c.Ref == nil {
+			for _, pi := range c.Value.ByIndex() {
+				if pi.Ref == nil {
+					pathItemOperations(pi.Value, collect)
+				}
+			}
+		}
+	}
+
+	for _, pi := range d.Components.PathItems {
+		if pi.Ref == nil {
+			pathItemOperations(pi.Value, collect)
+		}
+	}
+
+	for _, req := range security {
+		for name := range req {
+			reached["securitySchemes/"+string(name)] = true
+		}
+	}
+
+	var removed []string
+	removed = append(removed, prune(d.Components.Schemas, "schemas", reached)...)
+	removed = append(removed, prune(d.Components.Responses, "responses", reached)...)
+	removed = append(removed, prune(d.Components.Parameters, "parameters", reached)...)
+	removed = append(removed, prune(d.Components.RequestBodies, "requestBodies", reached)...)
+	removed = append(removed, prune(d.Components.Headers, "headers", reached)...)
+	removed = append(removed, prune(d.Components.SecuritySchemes, "securitySchemes", reached)...)
+
+	return removed, nil
+}
+
+// prune removes the components of the given kind that were not reached and returns references to them.
+func prune[M ~map[K]V, K ~string, V any](m M, tp string, reached map[string]bool) []string {
+	var removed []string
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		if !reached[tp+"/"+string(name)] {
+			delete(m, name)
+			removed = append(removed, newRef(tp, string(name)).Identifier)
+		}
+	}
+
+	return removed
+}
@@ -0,0 +1,85 @@
+package flatten
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Report lists the changes DocumentWithOptions made to a document.
+type Report struct {
+	// PathPrefix is the prefix shared by all paths that was moved into the server URLs, if any.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// Promotions lists the inline objects that were moved to or replaced by a component.
+	Promotions []Promotion `json:"promotions,omitempty"`
+	// HoistedParameters lists the parameters that were moved from the operations to their path item.
+	HoistedParameters []HoistedParameter `json:"hoistedParameters,omitempty"`
+	// PrunedComponents lists references to the components that were removed because nothing referenced them.
+	PrunedComponents []string `json:"prunedComponents,omitempty"`
+	// Diagnostics lists the problems found while flattening, see Options.SkipUnsupported.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// Promotion is an inline object that was replaced by a reference to a component.
+type Promotion struct {
+	// From is the location of the inline object, e.g. `paths["/pets"].POST.requestBody`.
+	From string `json:"from"`
+	// To is the reference to the component, e.g. "#/components/requestBodies/CreatePetRequestBody".
+	To string `json:"to"`
+	// Reason explains why the object was moved, e.g. "object with properties".
+	Reason string `json:"reason"`
+}
+
+// HoistedParameter is a parameter that all operations of a path item shared.
+type HoistedParameter struct {
+	// Path is the path of the path item, e.g. "/pets/{petId}".
+	Path string `json:"path"`
+	// Name is the name of the parameter.
+	Name string `json:"name"`
+	// In is the location of the parameter, e.g. "path" or "query".
+	In string `json:"in"`
+}
+
+// String formats the report as text, one change per line.
+func (r *Report) String() string {
+	b := &strings.Builder{}
+
+	if r.PathPrefix != "" {
+		fmt.Fprintf(b, "moved path prefix %q to the servers\n", r.PathPrefix)
+	}
+
+	for _, p := range r.Promotions {
+		fmt.Fprintf(b, "%s -> %s (%s)\n", p.From, p.To, p.Reason)
+	}
+
+	for _, p := range r.HoistedParameters {
+		fmt.Fprintf(b, "hoisted %s parameter %q to paths[%q]\n", p.In, p.Name, p.Path)
+	}
+
+	for _, ref := range r.PrunedComponents {
+		fmt.Fprintf(b, "removed unreferenced %s\n", ref)
+	}
+
+	for _, d := range r.Diagnostics {
+		fmt.Fprintln(b, d)
+	}
+
+	return b.String()
+}
+
+// promoted records that the inline object was replaced by a reference to the component, if there is a report.
+func (f *flattener) promoted(v any, tp, name, reason string) {
+	if loc, ok := f.locations[v]; ok && f.uses != nil {
+		key := tp + "/" + name
+		f.uses[key] = append(f.uses[key], loc)
+	}
+
+	if f.opts.Report == nil {
+		return
+	}
+
+	f.opts.Report.Promotions = append(f.opts.Report.Promotions, Promotion{
+		From:   f.locations[v],
+		To:     newRef(tp, name).Identifier,
+		Reason: reason,
+	})
+}
@@ -5,12 +5,12 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func requestBodies(d *openapi.Document, rs openapi.RequestBodies) error {
+func (f *flattener) requestBodies(rs openapi.RequestBodies) error {
 	for name, r := range rs.ByIndex() {
 		// NOTE: We are *not* calling RequestBodyRef here,
 		// because we are calling this function from Components,
 		// where the request body should already be.
-		if err := requestBody(d, r.Value, name); err != nil {
+		if err := f.requestBody(r.Value, name); err != nil {
 			return &errpath.ErrKey{Key: name, Err: err}
 		}
 	}
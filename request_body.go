@@ -9,23 +9,53 @@ func nameRequestBody(opID string) string {
 	return opID + "RequestBody"
 }
 
-func requestBody(d *openapi.Document, r *openapi.RequestBody, reqBodyName string) error {
-	if err := content(d, r.Content, reqBodyName, "RequestBody", moveIfNecessary); err != nil {
+func (f *flattener) requestBody(r *openapi.RequestBody, reqBodyName string) error {
+	if err := f.content(r.Content, reqBodyName, "RequestBody", moveIfNecessary); err != nil {
 		return &errpath.ErrField{Field: "content", Err: err}
 	}
 
 	return nil
 }
 
-func requestBodyRef(d *openapi.Document, r *openapi.RequestBodyRef, reqBodyName string) error {
+func (f *flattener) requestBodyRef(r *openapi.RequestBodyRef, reqBodyName string) error {
 	if r.Ref != nil {
 		return nil
 	}
 
+	if f.opts.InlineRequestBodies {
+		return f.requestBody(r.Value, reqBodyName)
+	}
+
+	key := ""
+	if f.opts.DeduplicateRequestBodies {
+		// reference an identical request body in the components instead of creating a copy
+		key = "requestBodies/" + fingerprint(r.Value)
+		if existing, ok := f.known[key]; ok {
+			f.promoted(r.Value, "requestBodies", existing, "identical request body")
+			r.Value = f.d.Components.RequestBodies[existing].Value
+			r.Ref = newRef("requestBodies", existing)
+			return nil
+		}
+	}
+
 	// reference the request body in the components
-	reqBodyName = uniqueName(d.Components.RequestBodies, reqBodyName)
-	d.Components.RequestBodies.Set(reqBodyName, &openapi.RequestBodyRef{Value: r.Value})
+	reqBodyName = componentName(f, f.d.Components.RequestBodies, r.Value, f.pinnedName(r.Value, reqBodyName))
+	f.d.Components.RequestBodies.Set(reqBodyName, &openapi.RequestBodyRef{Value: r.Value})
 	r.Ref = newRef("requestBodies", reqBodyName)
+	f.promoted(r.Value, "requestBodies", reqBodyName, "inline request body")
+	if key != "" {
+		f.known[key] = reqBodyName
+	}
+
+	return f.requestBody(r.Value, reqBodyName)
+}
 
-	return requestBody(d, r.Value, reqBodyName)
+// indexRequestBodies remembers the request bodies that are already in the components,
+// so that identical inline request bodies can reference them.
+func (f *flattener) indexRequestBodies() {
+	for name, r := range f.d.Components.RequestBodies.ByIndex() {
+		if r.Ref == nil {
+			f.known["requestBodies/"+fingerprint(r.Value)] = name
+		}
+	}
 }
@@ -1,6 +1,7 @@
 package flatten
 
 import (
+	"cmp"
 	"strings"
 
 	"github.com/MarkRosemaker/errpath"
@@ -18,31 +19,89 @@ func nameResponse(opID string, code openapi.StatusCode) string {
 	return strcase.ToGoPascal(strings.Join([]string{opID, statusText, "Response"}, " "))
 }
 
-func response(d *openapi.Document, r *openapi.Response, rspName string, modeSchema mode) error {
-	// if err := l.resolveHeaders(r.Headers); err != nil {
-	// 	return &errpath.ErrField{Field: "headers", Err: err}
-	// }
+func (f *flattener) response(r *openapi.Response, rspName string, modeSchema mode) error {
+	if err := f.headerRefs(r.Headers); err != nil {
+		return &errpath.ErrField{Field: "headers", Err: err}
+	}
 
-	if err := content(d, r.Content, rspName, "Response", modeSchema); err != nil {
+	if err := f.content(r.Content, rspName, "Response", modeSchema); err != nil {
 		return &errpath.ErrField{Field: "content", Err: err}
 	}
 
-	// if err := l.resolveLinks(r.Links); err != nil {
-	// 	return &errpath.ErrField{Field: "links", Err: err}
-	// }
+	f.linkRefs(r.Links)
 
 	return nil
 }
 
-func responseRef(d *openapi.Document, r *openapi.ResponseRef, rspName string, modeSchema mode) error {
+func (f *flattener) responseRef(r *openapi.ResponseRef, rspName string, modeSchema mode) error {
 	if r.Ref != nil {
 		return nil
 	}
 
+	if f.opts.InlineResponses {
+		return f.response(r.Value, rspName, modeSchema)
+	}
+
+	key := ""
+	if f.opts.DeduplicateResponses {
+		// reference an identical response in the components instead of creating a copy
+		key = "responses/" + fingerprint(r.Value)
+		if existing, ok := f.known[key]; ok {
+			f.promoted(r.Value, "responses", existing, "identical response")
+			r.Value = f.d.Components.Responses[existing].Value
+			r.Ref = newRef("responses", existing)
+			return nil
+		}
+
+		rspName = cmp.Or(f.shared[key], rspName)
+	}
+
+	rspName = f.pinnedName(r.Value, rspName)
+
 	// reference the response in the components
-	rspName = uniqueName(d.Components.Responses, rspName)
-	d.Components.Responses.Set(rspName, &openapi.ResponseRef{Value: r.Value})
+	rspName = componentName(f, f.d.Components.Responses, r.Value, rspName)
+	f.d.Components.Responses.Set(rspName, &openapi.ResponseRef{Value: r.Value})
 	r.Ref = newRef("responses", rspName)
+	f.promoted(r.Value, "responses", rspName, "inline response")
+	if key != "" {
+		f.known[key] = rspName
+	}
 
-	return response(d, r.Value, rspName, modeSchema) // flatten the response itself
+	return f.response(r.Value, rspName, modeSchema) // flatten the response itself
+}
+
+// indexResponses remembers the responses that are already in the components,
+// and names inline responses that are used more than once after their status code,
+// so that identical responses can share a component.
+func (f *flattener) indexResponses() {
+	for name, r := range f.d.Components.Responses.ByIndex() {
+		if r.Ref == nil {
+			f.known["responses/"+fingerprint(r.Value)] = name
+		}
+	}
+
+	counts := map[string]int{}
+	codes := map[string]openapi.StatusCode{}
+	for op := range f.operations() {
+		for code, r := range op.Responses.ByIndex() {
+			if r.Ref != nil {
+				continue
+			}
+
+			key := "responses/" + fingerprint(r.Value)
+			if _, ok := f.known[key]; ok {
+				continue
+			}
+
+			if counts[key]++; counts[key] == 1 {
+				codes[key] = code
+			}
+		}
+	}
+
+	for key, n := range counts {
+		if n > 1 {
+			f.shared[key] = f.namer.Response("", codes[key])
+		}
+	}
 }
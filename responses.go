@@ -5,14 +5,14 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func operationResponses(d *openapi.Document, rs openapi.OperationResponses, opID string) error {
+func (f *flattener) operationResponses(rs openapi.OperationResponses, opID string) error {
 	for code, r := range rs.ByIndex() {
-		modeSchema := alwaysMove
+		modeSchema := f.failureMode()
 		if code.IsSuccess() {
 			modeSchema = moveIfNecessary
 		}
 
-		if err := responseRef(d, r, nameResponse(opID, code), modeSchema); err != nil {
+		if err := f.responseRef(r, f.namer.Response(opID, code), modeSchema); err != nil {
 			return &errpath.ErrKey{Key: string(code), Err: err}
 		}
 	}
@@ -20,17 +20,17 @@ func operationResponses(d *openapi.Document, rs openapi.OperationResponses, opID
 	return nil
 }
 
-func responses(d *openapi.Document, rs openapi.ResponsesByName) error {
+func (f *flattener) responses(rs openapi.ResponsesByName) error {
 	for name, r := range rs.ByIndex() {
 		// NOTE: We are *not* calling responseRef here,
 		// because we are calling this function from Components,
 		// where the response should already be.
 		modeSchema := moveIfNecessary
-		if isFailureResponse(d, r) {
-			modeSchema = alwaysMove
+		if isFailureResponse(f.d, r) {
+			modeSchema = f.failureMode()
 		}
 
-		if err := response(d, r.Value, name, modeSchema); err != nil {
+		if err := f.response(r.Value, name, modeSchema); err != nil {
 			return &errpath.ErrKey{Key: string(name), Err: err}
 		}
 	}
@@ -38,6 +38,15 @@ func responses(d *openapi.Document, rs openapi.ResponsesByName) error {
 	return nil
 }
 
+// failureMode returns the mode for schemas of error responses.
+func (f *flattener) failureMode() mode {
+	if f.opts.LenientErrorSchemas {
+		return moveIfNecessary
+	}
+
+	return alwaysMove
+}
+
 func isFailureResponse(d *openapi.Document, r *openapi.ResponseRef) bool {
 	for _, p := range d.Paths {
 		for _, o := range p.Operations {
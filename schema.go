@@ -15,82 +15,109 @@ const (
 	neverMove
 )
 
-func schemaRef(d *openapi.Document, s *openapi.SchemaRef, name string, mode mode) error {
+func (f *flattener) schemaRef(s *openapi.SchemaRef, name string, mode mode) error {
 	if s.Ref != nil {
 		return nil // already processed
 	}
 
 	if mode == alwaysMove {
-		moveSchemaToComponents(d, name, s)
-
-		// process the schema itself
-		return schema(d, s.Value, name)
+		return f.promoteSchema(s, name, "error response")
 	}
 
-	switch s.Value.Type {
-	case openapi.TypeInteger, openapi.TypeNumber, openapi.TypeBoolean: // no need to move to components
+	reason := ""
+	switch f.schemaType(s.Value) {
+	case openapi.TypeInteger, openapi.TypeNumber, openapi.TypeBoolean, openapi.TypeNull: // no need to move to components
+	case "": // any value, or a schema with allOf
 	case openapi.TypeString:
-		if s.Value.Enum != nil && mode != neverMove {
-			moveSchemaToComponents(d, name, s)
+		if s.Value.Enum != nil && mode != neverMove && !f.opts.InlineEnums {
+			reason = "string enum"
 		} // else just string, no need to move to components
 	case openapi.TypeArray:
-		items := s.Value.Items.Value
-		switch items.Type {
+		items := f.innermostItems(s.Value)
+		if items == nil {
+			break // array of anything
+		}
+
+		switch f.schemaType(items) {
 		case openapi.TypeInteger: // do nothing, just []int
 		case openapi.TypeNumber: // do nothing, just []float32 or []float64
+		case openapi.TypeBoolean, openapi.TypeNull, "": // do nothing, just []bool or []any
 		case openapi.TypeString:
-			if items.Enum != nil && mode != neverMove {
-				moveSchemaToComponents(d, name, s)
+			if items.Enum != nil && mode != neverMove && !f.opts.InlineArrays {
+				reason = "array of string enums"
 			} // else just []string, no need to move to components
 		case openapi.TypeObject:
-			if len(items.Properties) > 0 && mode != neverMove {
-				moveSchemaToComponents(d, name, s)
+			if len(items.Properties) > 0 && mode != neverMove && !f.opts.InlineArrays {
+				reason = "array of objects"
 			}
-		case openapi.TypeArray: // TODO: later
 		default:
-			return fmt.Errorf("unimplemented item type %q", items.Type)
+			return f.unsupported(s.Value, fmt.Errorf("unimplemented item type %q", items.Type))
 		}
 	case openapi.TypeObject: // move to components
-		if len(s.Value.Properties) > 0 && mode != neverMove {
-			moveSchemaToComponents(d, name, s)
+		if len(s.Value.Properties) > 0 && mode != neverMove && !f.opts.InlineObjects {
+			reason = "object with properties"
 		}
 	default:
-		return fmt.Errorf("unimplemented schema ref type %q", s.Value.Type)
+		return f.unsupported(s.Value, fmt.Errorf("unimplemented schema ref type %q", s.Value.Type))
+	}
+
+	if reason != "" {
+		return f.promoteSchema(s, name, reason)
 	}
 
 	// process the schema itself
-	return schema(d, s.Value, name)
+	return f.schema(s.Value, name)
 }
 
-func schema(d *openapi.Document, s *openapi.Schema, name string) error {
+// promoteSchema moves the schema to the components and processes it.
+// The reason explains why the schema is moved.
+func (f *flattener) promoteSchema(s *openapi.SchemaRef, name, reason string) error {
+	name = f.pinnedName(s.Value, name)
+
+	if !f.moveSchemaToComponents(name, s, reason) {
+		return nil // an identical schema is in the components
+	}
+
+	// process the schema itself
+	return f.schema(s.Value, name)
+}
+
+func (f *flattener) schema(s *openapi.Schema, name string) error {
 	switch s.Type {
 	case openapi.TypeString,
 		openapi.TypeInteger,
 		openapi.TypeNumber,
-		openapi.TypeBoolean: // no need to do anything
+		openapi.TypeBoolean,
+		openapi.TypeNull: // no need to do anything
 		return nil
 	case openapi.TypeArray, openapi.TypeObject: // do below
-	case "": // is valid if schema contains allOf
+	case "": // any value, or a schema with allOf, properties or items
 	default:
-		return fmt.Errorf("unimplemented schema type %q", s.Type)
+		return f.unsupported(s, fmt.Errorf("unimplemented schema type %q", s.Type))
 	}
 
-	if err := schemaRefList(d, s.AllOf, name+"AllOf"); err != nil {
+	if err := f.schemaRefList(s.AllOf, name); err != nil {
 		return &errpath.ErrField{Field: "allOf", Err: err}
 	}
 
 	if s.Items != nil {
-		if err := schemaRef(d, s.Items, name+"Item", moveIfNecessary); err != nil {
+		// keep nested arrays inline, only their innermost items are moved
+		itemsMode := moveIfNecessary
+		if s.Items.Value != nil && f.schemaType(s.Items.Value) == openapi.TypeArray {
+			itemsMode = neverMove
+		}
+
+		if err := f.schemaRef(s.Items, f.namer.Item(name), itemsMode); err != nil {
 			return &errpath.ErrField{Field: "items", Err: err}
 		}
 	}
 
-	if err := schemaRefs(d, s.Properties, name); err != nil {
+	if err := f.schemaRefs(s.Properties, name); err != nil {
 		return &errpath.ErrField{Field: "properties", Err: err}
 	}
 
 	if s.AdditionalProperties != nil {
-		if err := schemaRef(d, s.AdditionalProperties, name+"Value", moveIfNecessary); err != nil {
+		if err := f.schemaRef(s.AdditionalProperties, f.namer.Value(name), moveIfNecessary); err != nil {
 			return &errpath.ErrField{Field: "additionalProperties", Err: err}
 		}
 	}
@@ -98,19 +125,79 @@ func schema(d *openapi.Document, s *openapi.Schema, name string) error {
 	return nil
 }
 
-func moveSchemaToComponents(d *openapi.Document, name string, s *openapi.SchemaRef) {
+// moveSchemaToComponents moves the schema to the components and references it.
+// With Options.DeduplicateSchemas, it references an identical schema in the components instead and returns false.
+func (f *flattener) moveSchemaToComponents(name string, s *openapi.SchemaRef, reason string) bool {
+	if f.opts.DeduplicateSchemas {
+		// reference an identical schema in the components instead of creating a copy
+		key := "schemas/" + schemaFingerprint(s.Value)
+		if existing, ok := f.known[key]; ok {
+			f.promoted(s.Value, "schemas", existing, "identical schema")
+			s.Value = f.d.Components.Schemas[existing]
+			s.Ref = newRef("schemas", existing)
+			return false
+		}
+
+		defer func() { f.known[key] = name }()
+	}
+
 	// reference the schema in the components
-	name = uniqueName(d.Components.Schemas, name)
-	d.Components.Schemas.Set(name, s.Value)
+	name = componentName(f, f.d.Components.Schemas, s.Value, name)
+	f.d.Components.Schemas.Set(name, s.Value)
 	s.Ref = newRef("schemas", name)
+	f.promoted(s.Value, "schemas", name, reason)
+
+	return true
+}
+
+// indexSchemas remembers the schemas that are already in the components,
+// so that identical inline schemas can reference them.
+func (f *flattener) indexSchemas() {
+	for name, s := range f.d.Components.Schemas.ByIndex() {
+		key := "schemas/" + schemaFingerprint(s)
+		if _, ok := f.known[key]; !ok {
+			f.known[key] = name
+		}
+	}
 }
 
-func schemaRefList(d *openapi.Document, ss openapi.SchemaRefList, prefix string) error {
+func (f *flattener) schemaRefList(ss openapi.SchemaRefList, name string) error {
 	for i, s := range ss {
-		if err := schemaRef(d, s, fmt.Sprintf("%s%d", prefix, i), neverMove); err != nil {
+		if err := f.schemaRef(s, f.namer.AllOf(name, i), neverMove); err != nil {
 			return &errpath.ErrIndex{Index: i, Err: err}
 		}
 	}
 
 	return nil
 }
+
+// schemaType returns the type of the schema. Unless Options.InlineTypeless is set,
+// a schema without a type is treated as an object if it has properties and as an array if it has items.
+func (f *flattener) schemaType(s *openapi.Schema) openapi.DataType {
+	if s.Type != "" || f.opts.InlineTypeless {
+		return s.Type
+	}
+
+	switch {
+	case len(s.Properties) > 0:
+		return openapi.TypeObject
+	case s.Items != nil:
+		return openapi.TypeArray
+	default:
+		return ""
+	}
+}
+
+// innermostItems returns the items of the innermost of nested arrays, e.g. Point for [][]Point.
+// It returns nil if an array has no items.
+func (f *flattener) innermostItems(s *openapi.Schema) *openapi.Schema {
+	for f.schemaType(s) == openapi.TypeArray {
+		if s.Items == nil {
+			return nil
+		}
+
+		s = s.Items.Value
+	}
+
+	return s
+}
@@ -1,18 +1,13 @@
 package flatten
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/MarkRosemaker/errpath"
 	"github.com/MarkRosemaker/openapi"
-	"github.com/ettle/strcase"
 )
 
-func schemaRefs(d *openapi.Document, ss openapi.SchemaRefs, prefix string) error {
+func (f *flattener) schemaRefs(ss openapi.SchemaRefs, prefix string) error {
 	for name, s := range ss.ByIndex() {
-		if err := schemaRef(d, s,
-			strcase.ToGoPascal(fmt.Sprintf("%s %s", prefix, strings.ReplaceAll(name, "/", " "))), moveIfNecessary); err != nil {
+		if err := f.schemaRef(s, f.namer.Property(prefix, name), moveIfNecessary); err != nil {
 			return &errpath.ErrKey{Key: name, Err: err}
 		}
 	}
@@ -5,9 +5,9 @@ import (
 	"github.com/MarkRosemaker/openapi"
 )
 
-func schemas(d *openapi.Document, ss openapi.Schemas) error {
+func (f *flattener) schemas(ss openapi.Schemas) error {
 	for name, s := range ss.ByIndex() {
-		if err := schema(d, s, name); err != nil {
+		if err := f.schema(s, name); err != nil {
 			return &errpath.ErrKey{Key: name, Err: err}
 		}
 	}
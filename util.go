@@ -1,6 +1,11 @@
 package flatten
 
-import "fmt"
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 func uniqueName[M ~map[string]V, V any](m M, name string) string {
 	idx := 1
@@ -17,3 +22,142 @@ func uniqueName[M ~map[string]V, V any](m M, name string) string {
 		altName = fmt.Sprintf("%s%d", name, idx)
 	}
 }
+
+// componentName returns a name for the object that is not taken in the components yet.
+// With Options.StableNames, a taken name is made unique with words from the location of the object instead of a number.
+// A pinned name that is taken is reported as an error diagnostic.
+func componentName[M ~map[string]V, V any](f *flattener, m M, v any, name string) string {
+	loc, ok := f.locations[v]
+	if !ok {
+		return uniqueName(m, name)
+	}
+
+	// an object that shares its component is named by the same location, whichever of them comes first
+	nameLoc := loc
+	if c, ok := f.canonical[loc]; ok {
+		nameLoc = c
+	}
+
+	key := fmt.Sprintf("%T/%s", v, name)
+	if f.requested != nil {
+		f.requested[key] = append(f.requested[key], nameLoc)
+	}
+
+	var altName string
+	if f.opts.StableNames {
+		var group [][]string
+		for _, other := range f.collisions[key] {
+			group = append(group, f.locationWords(other))
+		}
+
+		altName = stableName(m, name, f.locationWords(nameLoc), group, f.namer)
+	} else {
+		altName = uniqueName(m, name)
+	}
+
+	if altName != name && f.opts.Names[loc] == name {
+		f.diagnostics = append(f.diagnostics, Diagnostic{
+			Severity: SeverityError,
+			Location: loc,
+			Message:  fmt.Sprintf("pinned name %s is taken, named it %s", name, altName),
+		})
+	}
+
+	return altName
+}
+
+// stableName makes the name unique with words from the location of the object,
+// e.g. "filter" at `paths["/search"].GET.parameters[0]` becomes "filterSearch" and then "filterSearchGet".
+//
+// The group holds the words of the locations of all objects that ask for the name.
+// Each of them gets as many words as are needed to tell the group apart, even the first one visited,
+// so that the name does not depend on the order in which the objects are visited.
+func stableName[M ~map[string]V, V any](m M, name string, words []string, group [][]string, namer Namer) string {
+	suffixed := func(words []string, n int) string {
+		if n = min(n, len(words)); n == 0 {
+			return name
+		}
+
+		return name + namer.Operation(strings.Join(words[:n], " "))
+	}
+
+	n := 0
+	if len(group) > 1 {
+		longest := 0
+		for _, w := range group {
+			longest = max(longest, len(w))
+		}
+
+		// the fewest words that tell the group apart
+		for n = 1; n < longest; n++ {
+			names := map[string]bool{}
+			for _, w := range group {
+				names[suffixed(w, n)] = true
+			}
+
+			if len(names) == len(group) {
+				break
+			}
+		}
+	}
+
+	for ; n <= len(words); n++ {
+		if altName := suffixed(words, n); !isTaken(m, altName) {
+			return altName
+		}
+	}
+
+	// the location does not tell the objects apart
+	return uniqueName(m, suffixed(words, len(words)))
+}
+
+func isTaken[M ~map[string]V, V any](m M, name string) bool {
+	_, ok := m[name]
+	return ok
+}
+
+// locationWords returns the words of the location like locationWords,
+// without the path prefix that was moved to the server URLs.
+func (f *flattener) locationWords(loc string) []string {
+	words := locationWords(loc)
+	if f.pathPrefix != "" && strings.HasPrefix(loc, "paths[") && len(words) > 0 {
+		words[0] = cmp.Or(strings.TrimPrefix(words[0], f.pathPrefix), "/")
+	}
+
+	return words
+}
+
+// locationWords returns the keys and HTTP methods of a location,
+// e.g. "/search", "GET" and "200" for `paths["/search"].GET.responses["200"]`.
+func locationWords(loc string) []string {
+	var words []string
+	for loc != "" {
+		switch loc[0] {
+		case '[':
+			loc = loc[1:]
+			if key, err := strconv.QuotedPrefix(loc); err == nil {
+				loc = loc[len(key):]
+				key, _ = strconv.Unquote(key)
+				words = append(words, key)
+			}
+
+			// skip to the end of the key or index
+			_, loc, _ = strings.Cut(loc, "]")
+		case '.':
+			loc = loc[1:]
+		default:
+			end := strings.IndexAny(loc, ".[")
+			if end < 0 {
+				end = len(loc)
+			}
+
+			if field := loc[:end]; field == strings.ToUpper(field) {
+				words = append(words, field) // an HTTP method
+			}
+
+			loc = loc[end:]
+		}
+	}
+
+	return words
+}
@@ -0,0 +1,474 @@
+package flatten
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+)
+
+// walker walks the objects that Document flattens and calls visit for each reference to a component.
+//
+// Each method is given the location of the object it walks,
+// in the same format errpath prints, e.g. `paths["/pets"].GET.requestBody`.
+type walker struct {
+	// visit is called with the kind and name of a referenced component, e.g. "schemas" and "Pet".
+	// If it returns true, the walker follows the reference and walks the component.
+	// If it is nil, references are not followed.
+	visit func(tp, name string) (bool, error)
+	// inline removes the references the walker follows, which puts the component in their place.
+	inline bool
+	// object, if set, is called with each inline object and its location,
+	// i.e. path items, parameters, request bodies, responses, headers, examples, links and schemas.
+	object func(v any, loc string)
+	// lookup, if set, holds the components that the references the loader left unresolved,
+	// i.e. those in the callbacks of operations, are resolved to, so that they can be followed.
+	lookup *openapi.Components
+}
+
+// document walks the paths, webhooks and components of the document.
+func (w *walker) document(d *openapi.Document) error {
+	if err := w.paths(d.Paths, "paths"); err != nil {
+		return &errpath.ErrField{Field: "paths", Err: err}
+	}
+
+	if err := w.webhooks(d.Webhooks, "webhooks"); err != nil {
+		return &errpath.ErrField{Field: "webhooks", Err: err}
+	}
+
+	if err := w.components(d.Components, "components"); err != nil {
+		return &errpath.ErrField{Field: "components", Err: err}
+	}
+
+	return nil
+}
+
+// found calls the object hook, if any.
+func (w *walker) found(v any, loc string) {
+	if w.object != nil {
+		w.object(v, loc)
+	}
+}
+
+func (w *walker) paths(ps openapi.Paths, loc string) error {
+	for p, pi := range ps.ByIndex() {
+		if err := w.pathItem(pi, locKey(loc, string(p))); err != nil {
+			return &errpath.ErrKey{Key: string(p), Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) webhooks(ws openapi.Webhooks, loc string) error {
+	for _, name := range slices.Sorted(maps.Keys(ws)) {
+		if err := w.pathItemRef(ws[name], locKey(loc, name)); err != nil {
+			return &errpath.ErrKey{Key: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) components(c openapi.Components, loc string) error {
+	for name, s := range c.Schemas.ByIndex() {
+		if err := w.schema(s, locKey(locField(loc, "schemas"), name)); err != nil {
+			return &errpath.ErrField{Field: "schemas", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	for name, r := range c.Responses.ByIndex() {
+		if err := w.responseRef(r, locKey(locField(loc, "responses"), name)); err != nil {
+			return &errpath.ErrField{Field: "responses", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	for name, p := range c.Parameters.ByIndex() {
+		if err := w.parameterRef(p, locKey(locField(loc, "parameters"), name)); err != nil {
+			return &errpath.ErrField{Field: "parameters", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	for name, r := range c.RequestBodies.ByIndex() {
+		if err := w.requestBodyRef(r, locKey(locField(loc, "requestBodies"), name)); err != nil {
+			return &errpath.ErrField{Field: "requestBodies", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	for name, h := range c.Headers.ByIndex() {
+		if err := w.headerRef(h, locKey(locField(loc, "headers"), name)); err != nil {
+			return &errpath.ErrField{Field: "headers", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	if err := w.callbackRefs(c.Callbacks, locField(loc, "callbacks")); err != nil {
+		return &errpath.ErrField{Field: "callbacks", Err: err}
+	}
+
+	if err := w.pathItems(c.PathItems, locField(loc, "pathItems")); err != nil {
+		return &errpath.ErrField{Field: "pathItems", Err: err}
+	}
+
+	return nil
+}
+
+func (w *walker) callbackRefs(cs openapi.CallbackRefs, loc string) error {
+	for name, c := range cs.ByIndex() {
+		if c.Ref != nil {
+			continue
+		}
+
+		if err := w.callback(*c.Value, locKey(loc, name)); err != nil {
+			return &errpath.ErrKey{Key: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) pathItems(pis openapi.PathItems, loc string) error {
+	for name, pi := range pis.ByIndex() {
+		if err := w.pathItemRef(pi, locKey(loc, name)); err != nil {
+			return &errpath.ErrKey{Key: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// follow tells whether to follow the reference to a component of the given kind.
+// The value is a pointer to the value of the reference, which is resolved if the loader left it unresolved.
+func (w *walker) follow(tp string, ref *openapi.Reference, value any) (bool, error) {
+	if w.visit == nil {
+		return false, nil
+	}
+
+	name, ok := strings.CutPrefix(ref.Identifier, "#/components/"+tp+"/")
+	if !ok {
+		return false, nil // e.g. a reference to another document
+	}
+
+	name = unescapePointer(name)
+	if !w.resolve(name, value) {
+		return false, fmt.Errorf("unresolved reference %q", ref.Identifier)
+	}
+
+	return w.visit(tp, name)
+}
+
+// resolve sets the value of a reference to the component with the given name, unless it is set already,
+// and tells whether the reference has a value.
+func (w *walker) resolve(name string, value any) bool {
+	c := w.lookup
+	if c == nil {
+		c = &openapi.Components{}
+	}
+
+	switch v := value.(type) {
+	case **openapi.Schema:
+		if *v == nil {
+			*v = c.Schemas[name]
+		}
+
+		return *v != nil
+	case **openapi.Response:
+		if r := c.Responses[name]; *v == nil && r != nil {
+			*v = r.Value
+		}
+
+		return *v != nil
+	case **openapi.Parameter:
+		if p := c.Parameters[name]; *v == nil && p != nil {
+			*v = p.Value
+		}
+
+		return *v != nil
+	case **openapi.RequestBody:
+		if r := c.RequestBodies[name]; *v == nil && r != nil {
+			*v = r.Value
+		}
+
+		return *v != nil
+	case **openapi.Header:
+		if h := c.Headers[name]; *v == nil && h != nil {
+			*v = h.Value
+		}
+
+		return *v != nil
+	}
+
+	return false
+}
+
+// unescapePointer unescapes a JSON pointer token, see RFC 6901.
+func unescapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+func (w *walker) pathItemRef(pi *openapi.PathItemRef, loc string) error {
+	if pi.Ref != nil {
+		return nil
+	}
+
+	return w.pathItem(pi.Value, loc)
+}
+
+func (w *walker) pathItem(pi *openapi.PathItem, loc string) error {
+	w.found(pi, loc)
+
+	if err := w.parameterList(pi.Parameters, locField(loc, "parameters")); err != nil {
+		return &errpath.ErrField{Field: "parameters", Err: err}
+	}
+
+	for method, op := range pi.Operations {
+		if err := w.operation(op, locField(loc, method)); err != nil {
+			return &errpath.ErrField{Field: method, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) operation(o *openapi.Operation, loc string) error {
+	if err := w.parameterList(o.Parameters, locField(loc, "parameters")); err != nil {
+		return &errpath.ErrField{Field: "parameters", Err: err}
+	}
+
+	if o.RequestBody != nil {
+		if err := w.requestBodyRef(o.RequestBody, locField(loc, "requestBody")); err != nil {
+			return &errpath.ErrField{Field: "requestBody", Err: err}
+		}
+	}
+
+	for code, r := range o.Responses.ByIndex() {
+		if err := w.responseRef(r, locKey(locField(loc, "responses"), string(code))); err != nil {
+			return &errpath.ErrField{Field: "responses", Err: &errpath.ErrKey{Key: string(code), Err: err}}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(o.Callbacks)) {
+		if err := w.callback(o.Callbacks[name], locKey(locField(loc, "callbacks"), name)); err != nil {
+			return &errpath.ErrField{Field: "callbacks", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) callback(c openapi.Callback, loc string) error {
+	for expr, pi := range c.ByIndex() {
+		if err := w.pathItemRef(pi, locKey(loc, string(expr))); err != nil {
+			return &errpath.ErrKey{Key: string(expr), Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) parameterList(ps openapi.ParameterList, loc string) error {
+	for idx, p := range ps {
+		if err := w.parameterRef(p, locIndex(loc, idx)); err != nil {
+			return &errpath.ErrIndex{Index: idx, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) parameterRef(p *openapi.ParameterRef, loc string) error {
+	if p.Ref != nil {
+		if follow, err := w.follow("parameters", p.Ref, &p.Value); err != nil || !follow {
+			return err
+		}
+
+		if w.inline {
+			p.Ref = nil
+		}
+	} else {
+		w.found(p.Value, loc)
+	}
+
+	if p.Value.Schema != nil {
+		if err := w.schema(p.Value.Schema, locField(loc, "schema")); err != nil {
+			return &errpath.ErrField{Field: "schema", Err: err}
+		}
+	}
+
+	if err := w.content(p.Value.Content, locField(loc, "content")); err != nil {
+		return &errpath.ErrField{Field: "content", Err: err}
+	}
+
+	w.examples(p.Value.Examples, locField(loc, "examples"))
+
+	return nil
+}
+
+func (w *walker) requestBodyRef(r *openapi.RequestBodyRef, loc string) error {
+	if r.Ref != nil {
+		if follow, err := w.follow("requestBodies", r.Ref, &r.Value); err != nil || !follow {
+			return err
+		}
+
+		if w.inline {
+			r.Ref = nil
+		}
+	} else {
+		w.found(r.Value, loc)
+	}
+
+	if err := w.content(r.Value.Content, locField(loc, "content")); err != nil {
+		return &errpath.ErrField{Field: "content", Err: err}
+	}
+
+	return nil
+}
+
+func (w *walker) responseRef(r *openapi.ResponseRef, loc string) error {
+	if r.Ref != nil {
+		if follow, err := w.follow("responses", r.Ref, &r.Value); err != nil || !follow {
+			return err
+		}
+
+		if w.inline {
+			r.Ref = nil
+		}
+	} else {
+		w.found(r.Value, loc)
+	}
+
+	for name, h := range r.Value.Headers.ByIndex() {
+		if err := w.headerRef(h, locKey(locField(loc, "headers"), name)); err != nil {
+			return &errpath.ErrField{Field: "headers", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	if err := w.content(r.Value.Content, locField(loc, "content")); err != nil {
+		return &errpath.ErrField{Field: "content", Err: err}
+	}
+
+	// links are not followed, since they can't reference other components
+	for key, l := range r.Value.Links.ByIndex() {
+		if l.Ref == nil {
+			w.found(l.Value, locKey(locField(loc, "links"), key))
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) headerRef(h *openapi.HeaderRef, loc string) error {
+	if h.Ref != nil {
+		if follow, err := w.follow("headers", h.Ref, &h.Value); err != nil || !follow {
+			return err
+		}
+
+		if w.inline {
+			h.Ref = nil
+		}
+	} else {
+		w.found(h.Value, loc)
+	}
+
+	if h.Value.Schema != nil {
+		if err := w.schema(h.Value.Schema, locField(loc, "schema")); err != nil {
+			return &errpath.ErrField{Field: "schema", Err: err}
+		}
+	}
+
+	if err := w.content(h.Value.Content, locField(loc, "content")); err != nil {
+		return &errpath.ErrField{Field: "content", Err: err}
+	}
+
+	w.examples(h.Value.Examples, locField(loc, "examples"))
+
+	return nil
+}
+
+func (w *walker) content(c openapi.Content, loc string) error {
+	for mr, mt := range c.ByIndex() {
+		mtLoc := locKey(loc, string(mr))
+
+		if mt.Schema != nil {
+			if err := w.schemaRef(mt.Schema, locField(mtLoc, "schema")); err != nil {
+				return &errpath.ErrKey{Key: string(mr), Err: &errpath.ErrField{Field: "schema", Err: err}}
+			}
+		}
+
+		w.examples(mt.Examples, locField(mtLoc, "examples"))
+
+		if err := w.encodings(mt.Encoding, locField(mtLoc, "encoding")); err != nil {
+			return &errpath.ErrKey{Key: string(mr), Err: &errpath.ErrField{Field: "encoding", Err: err}}
+		}
+	}
+
+	return nil
+}
+
+// examples reports the inline examples; references to examples are not followed,
+// since examples can't reference other components.
+func (w *walker) examples(exs openapi.Examples, loc string) {
+	for key, ex := range exs.ByIndex() {
+		if ex.Ref == nil {
+			w.found(ex.Value, locKey(loc, key))
+		}
+	}
+}
+
+func (w *walker) encodings(es openapi.Encodings, loc string) error {
+	for prop, e := range es.ByIndex() {
+		for name, h := range e.Headers.ByIndex() {
+			if err := w.headerRef(h, locKey(locField(locKey(loc, prop), "headers"), name)); err != nil {
+				return &errpath.ErrKey{Key: prop, Err: &errpath.ErrField{Field: "headers", Err: &errpath.ErrKey{Key: name, Err: err}}}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) schemaRef(s *openapi.SchemaRef, loc string) error {
+	if s.Ref != nil {
+		if follow, err := w.follow("schemas", s.Ref, &s.Value); err != nil || !follow {
+			return err
+		}
+
+		if w.inline {
+			s.Ref = nil
+		}
+	}
+
+	return w.schema(s.Value, loc)
+}
+
+func (w *walker) schema(s *openapi.Schema, loc string) error {
+	w.found(s, loc)
+
+	for idx, s := range s.AllOf {
+		if err := w.schemaRef(s, locIndex(locField(loc, "allOf"), idx)); err != nil {
+			return &errpath.ErrField{Field: "allOf", Err: &errpath.ErrIndex{Index: idx, Err: err}}
+		}
+	}
+
+	if s.Items != nil {
+		if err := w.schemaRef(s.Items, locField(loc, "items")); err != nil {
+			return &errpath.ErrField{Field: "items", Err: err}
+		}
+	}
+
+	for name, s := range s.Properties.ByIndex() {
+		if err := w.schemaRef(s, locKey(locField(loc, "properties"), name)); err != nil {
+			return &errpath.ErrField{Field: "properties", Err: &errpath.ErrKey{Key: name, Err: err}}
+		}
+	}
+
+	if s.AdditionalProperties != nil {
+		if err := w.schemaRef(s.AdditionalProperties, locField(loc, "additionalProperties")); err != nil {
+			return &errpath.ErrField{Field: "additionalProperties", Err: err}
+		}
+	}
+
+	return nil
+}
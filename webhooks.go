@@ -0,0 +1,20 @@
+package flatten
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/MarkRosemaker/errpath"
+	"github.com/MarkRosemaker/openapi"
+)
+
+func (f *flattener) webhooks(ws openapi.Webhooks) error {
+	// webhooks are not ordered, so we sort them to get stable names
+	for _, name := range slices.Sorted(maps.Keys(ws)) {
+		if err := f.pathItemRef(ws[name], f.namer.Operation(name)); err != nil {
+			return &errpath.ErrKey{Key: name, Err: err}
+		}
+	}
+
+	return nil
+}